@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"prinkbenchmarking/src/analysis"
+	cfg "prinkbenchmarking/src/config"
+	"prinkbenchmarking/src/evaluation"
+	"prinkbenchmarking/src/exporter"
+	"prinkbenchmarking/src/prink"
+	"prinkbenchmarking/src/report"
+	"prinkbenchmarking/src/types"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+)
+
+// errUsage is returned for invalid command lines, the usage has already been printed.
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	// assigned in init, as the help command refers to commands itself
+	commands = []command{
+		{"run", "run all experiments of the campaign (default)", runCampaign},
+		{"single", "run a single experiment", runSingle},
+		{"listen", "only open the sockets of a single experiment, Prink is started elsewhere", runListen},
+		{"plan", "print the experiments of the campaign and their state without running them", runPlan},
+		{"analyze", "summarize the results in the output folder", runAnalyze},
+		{"report", "render an HTML and Markdown report of the results in the output folder", runReport},
+		{"cleanup", "remove the containers and networks of a campaign or experiment from the SUT hosts", runCleanup},
+		{"help", "print this help", runHelp},
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: client [command] [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'client <command> -h' for the flags of a command.\n")
+}
+
+func runCommand(args []string) error {
+	if len(args) == 0 {
+		return runCampaign(nil)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+	usage()
+	return errUsage
+}
+
+func runHelp(args []string) error {
+	usage()
+	return nil
+}
+
+// newFlagSet returns a flag set for the command which reports usage errors instead of exiting.
+func newFlagSet(name string, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: client %s [flags]\n\n%s.\n\nFlags:\n", name, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the arguments and rejects positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected arguments: %v\n\n", fs.Args())
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+type configOverride struct {
+	name  string
+	usage string
+	apply func(config *types.Config, value string) error
+}
+
+func intOverride(set func(config *types.Config, value int)) func(config *types.Config, value string) error {
+	return func(config *types.Config, value string) error {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		set(config, v)
+		return nil
+	}
+}
+
+// configOverrides are the config settings which can be overridden on the command line, named like their yaml keys.
+var configOverrides = []configOverride{
+	{"sut_addresses", "comma separated addresses of the SUT hosts", func(c *types.Config, v string) error {
+		c.SutAddresses = strings.Split(v, ",")
+		return nil
+	}},
+	{"local_address", "address under which the SUT reaches the client", func(c *types.Config, v string) error {
+		c.LocalAddress = v
+		return nil
+	}},
+	{"sut_docker_host_template", "template of the Docker host of a SUT address", func(c *types.Config, v string) error {
+		c.SutDockerHostTemplate = v
+		return nil
+	}},
+	{"sut_port_write", "port the client writes records to", intOverride(func(c *types.Config, v int) { c.PortWrite = v })},
+	{"sut_port_read", "port the client reads Prink's output from", intOverride(func(c *types.Config, v int) { c.PortRead = v })},
+	{"output_folder", "folder the results are written to", func(c *types.Config, v string) error {
+		c.OutputFolder = v
+		return nil
+	}},
+	{"input_data", "dataset sent to Prink", func(c *types.Config, v string) error {
+		c.InputData = v
+		return nil
+	}},
+	{"taskmanager_memory", "memory of the Flink taskmanager", func(c *types.Config, v string) error {
+		c.TaskManagerMemory = v
+		return nil
+	}},
+	{"prom-address", "address of the client's Prometheus exporter", func(c *types.Config, v string) error {
+		c.PrometheusExporterAddress = v
+		return nil
+	}},
+	{"prink_docker_image", "Prink image to run", func(c *types.Config, v string) error {
+		c.PrinkDockerImage = v
+		return nil
+	}},
+	{"sut_mode", "system under test, docker or fake", func(c *types.Config, v string) error {
+		c.SutMode = v
+		return nil
+	}},
+	{"campaign", "campaign file declaring the experiments", func(c *types.Config, v string) error {
+		c.Campaign = v
+		return nil
+	}},
+	{"campaign_id", "label of the containers of the campaign", func(c *types.Config, v string) error {
+		c.CampaignID = v
+		return nil
+	}},
+}
+
+// addConfigFlags registers the config flags and returns a function loading the config with the overrides applied.
+func addConfigFlags(fs *flag.FlagSet) func() (*types.Config, error) {
+	path := fs.String("config", "", "config file to use instead of $CLIENT_CONFIG or config.yml")
+	for _, override := range configOverrides {
+		fs.String(override.name, "", "override "+override.usage)
+	}
+
+	return func() (*types.Config, error) {
+		var config *types.Config
+		if *path != "" {
+			var err error
+			if config, err = cfg.ReadConfigFromFile(*path); err != nil {
+				return nil, fmt.Errorf("could not read config %s: %v", *path, err)
+			}
+		} else {
+			config = cfg.LoadConfig()
+		}
+
+		var err error
+		fs.Visit(func(f *flag.Flag) {
+			for _, override := range configOverrides {
+				if override.name == f.Name && err == nil {
+					if applyErr := override.apply(config, f.Value.String()); applyErr != nil {
+						err = fmt.Errorf("invalid value for -%s: %v", f.Name, applyErr)
+					}
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return config, cfg.ValidateConfig(config)
+	}
+}
+
+// addExperimentFlags registers a flag per experiment parameter and returns a function building the experiment.
+func addExperimentFlags(fs *flag.FlagSet) func() types.Experiment {
+	defaults := types.DefaultExperiment()
+	values := map[string]*int{}
+	for _, name := range types.ExperimentParameters() {
+		field, _ := defaults.Parameter(name)
+		values[name] = fs.Int(name, *field, "experiment parameter "+name)
+	}
+	runID := fs.Int("run_id", 0, "run id of the experiment")
+
+	return func() types.Experiment {
+		experiment := types.DefaultExperiment()
+		for name, value := range values {
+			field, _ := experiment.Parameter(name)
+			*field = *value
+		}
+		experiment.RunId = *runID
+		return experiment
+	}
+}
+
+// interruptContext returns a context which is cancelled with the signal as cause on SIGINT or SIGTERM,
+// so the running experiments are stopped and their results saved. A second signal exits immediately.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		signal.Stop(signals)
+		log.Printf("Received %v, shutting down. Send it again to exit immediately", sig)
+		cancel(fmt.Errorf("received %v", sig))
+	}()
+	return ctx
+}
+
+// startClient returns the address of the client and starts the Prometheus exporter.
+func startClient(config *types.Config) string {
+	localIP := config.LocalAddress
+	if localIP == "" {
+		localIP = GetOutboundIP().String()
+	}
+
+	log.Printf("Local IP: %s", localIP)
+
+	// Start Prometheus exporter and register metrics
+	exporter.Configure(config.Schema, config.MetricBuffer)
+	go exporter.StartPrometheusExporter(config.PrometheusExporterAddress)
+	if config.Push.URL != "" {
+		log.Printf("Pushing metrics to %s", config.Push.URL)
+		exporter.StartPusher(config.Push)
+	}
+
+	return localIP
+}
+
+// stopClient pushes the samples which are still buffered when the client stops.
+func stopClient() {
+	if err := exporter.StopPusher(); err != nil {
+		log.Println("Error in pushing metrics: ", err)
+	}
+}
+
+func runCampaign(args []string) error {
+	fs := newFlagSet("run", "Run all experiments of the campaign")
+	loadConfig := addConfigFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := interruptContext()
+	localIP := startClient(config)
+	defer stopClient()
+	StartExperiments(ctx, localIP, config, nil)
+
+	if ctx.Err() != nil {
+		// the campaign is not done, so the cluster must not be stopped
+		return fmt.Errorf("campaign interrupted, see %s/campaign_status.json", config.OutputFolder)
+	}
+	experimentDone()
+	log.Printf("Created output files in: %s", config.OutputFolder)
+	return nil
+}
+
+func runSingle(args []string) error {
+	fs := newFlagSet("single", "Run a single experiment on the first SUT address")
+	loadConfig := addConfigFlags(fs)
+	experimentFromFlags := addExperimentFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(config.SutAddresses) == 0 {
+		return fmt.Errorf("no SUT address configured")
+	}
+	config.SutAddresses = config.SutAddresses[:1]
+
+	experiment := experimentFromFlags()
+	if err := experiment.Validate(); err != nil {
+		return err
+	}
+	ctx := interruptContext()
+	localIP := startClient(config)
+	defer stopClient()
+	log.Printf("Running in one-experiment mode: %v", experiment)
+
+	StartExperiments(ctx, localIP, config, []types.Experiment{experiment})
+	if ctx.Err() != nil {
+		return fmt.Errorf("experiment interrupted")
+	}
+	return nil
+}
+
+func runListen(args []string) error {
+	fs := newFlagSet("listen", "Only open the sockets of a single experiment, Prink is started elsewhere")
+	loadConfig := addConfigFlags(fs)
+	experimentFromFlags := addExperimentFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(config.SutAddresses) == 0 {
+		return fmt.Errorf("no SUT address configured")
+	}
+
+	experiment := experimentFromFlags()
+	experiment.LocalHost = startClient(config)
+	defer stopClient()
+	experiment.SutHost = config.SutAddresses[0]
+	experiment.SutPortWrite = config.PortWrite
+	experiment.SutPortRead = config.PortRead
+
+	if err := evaluation.RunSockets(interruptContext(), &experiment, *config); err != nil {
+		return fmt.Errorf("experiment %v failed: %v", experiment, err)
+	}
+	return nil
+}
+
+func runPlan(args []string) error {
+	fs := newFlagSet("plan", "Print the experiments of the campaign and their state without running them")
+	loadConfig := addConfigFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	experiments, err := cfg.LoadExperiments(config)
+	if err != nil {
+		return fmt.Errorf("could not load experiments: %v", err)
+	}
+	journal, err := evaluation.OpenJournal(config.OutputFolder)
+	if err != nil {
+		return fmt.Errorf("could not open journal: %v", err)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(append(types.ExperimentKeys(), "state", "tries"), "\t"))
+	remaining := 0
+	for _, experiment := range experiments {
+		entry := journal.Entry(experiment)
+		if entry.Remaining(config.Retry) {
+			remaining++
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\n", strings.Join(experiment.ToLabels(), "\t"), entry.State, entry.Tries)
+	}
+	writer.Flush()
+
+	fmt.Printf("\n%d experiments, %d left to run\n", len(experiments), remaining)
+	return nil
+}
+
+func runAnalyze(args []string) error {
+	fs := newFlagSet("analyze", "Summarize the results in the output folder")
+	loadConfig := addConfigFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// analyze the results of earlier runs, no SUT needed
+	summaries, err := analysis.Analyze(*config)
+	if err != nil {
+		return fmt.Errorf("could not analyze results: %v", err)
+	}
+	if err := analysis.SaveSummaries(config.OutputFolder+"/analysis", summaries); err != nil {
+		return fmt.Errorf("could not save summaries: %v", err)
+	}
+	if err := analysis.SaveInfoLoss(config.OutputFolder+"/analysis", summaries); err != nil {
+		return fmt.Errorf("could not save information loss: %v", err)
+	}
+	log.Printf("Analyzed %d experiments into %s/analysis", len(summaries), config.OutputFolder)
+	return nil
+}
+
+func runReport(args []string) error {
+	fs := newFlagSet("report", "Render an HTML and Markdown report of the results in the output folder")
+	loadConfig := addConfigFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	r, err := report.Generate(*config)
+	if err != nil {
+		return fmt.Errorf("could not generate report: %v", err)
+	}
+	if err := r.Save(config.OutputFolder + "/report"); err != nil {
+		return fmt.Errorf("could not save report: %v", err)
+	}
+	log.Printf("Reported %d experiments into %s/report", len(r.Summaries), config.OutputFolder)
+	return nil
+}
+
+func runCleanup(args []string) error {
+	fs := newFlagSet("cleanup", "Remove the containers and networks of a campaign or experiment from the SUT hosts")
+	loadConfig := addConfigFlags(fs)
+	experiment := fs.String("experiment", "", "only remove the resources of this experiment, e.g. "+types.DefaultExperiment().ToFileName())
+	allCampaigns := fs.Bool("all_campaigns", false, "remove the resources of all campaigns instead of campaign_id")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	filter := prink.CleanupFilter{Campaign: config.CampaignID, Experiment: *experiment}
+	if *allCampaigns {
+		filter.Campaign = ""
+	}
+
+	for _, address := range config.SutAddresses {
+		dockerHost, err := prink.GetDockerHost(address, *config)
+		if err != nil {
+			return err
+		}
+		log.Printf("Cleaning up %s", dockerHost)
+		if err := prink.CleanupPrink(dockerHost, filter); err != nil {
+			return fmt.Errorf("could not clean up %s: %v", dockerHost, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"prinkbenchmarking/src/types"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunCommand(t *testing.T) {
+	if err := runCommand([]string{"unknown"}); !errors.Is(err, errUsage) {
+		t.Errorf("unknown command returned %v, want %v", err, errUsage)
+	}
+}
+
+func TestConfigFlags(t *testing.T) {
+	path := t.TempDir() + "/config.yml"
+	config := `
+sut_addresses: [10.0.0.1]
+sut_port_write: 50051
+sut_port_read: 50052
+output_folder: results
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		check   func(config *types.Config) bool
+		wantErr error
+		// part of the error message if the config cannot be loaded
+		wantLoadErr string
+	}{
+		{
+			name:  "config file",
+			args:  []string{"-config", path},
+			check: func(c *types.Config) bool { return c.PortWrite == 50051 && c.OutputFolder == "results" },
+		},
+		{
+			name:  "string override",
+			args:  []string{"-config", path, "-output_folder", "other"},
+			check: func(c *types.Config) bool { return c.OutputFolder == "other" },
+		},
+		{
+			name:  "int override",
+			args:  []string{"-config", path, "-sut_port_write", "40000"},
+			check: func(c *types.Config) bool { return c.PortWrite == 40000 && c.PortRead == 50052 },
+		},
+		{
+			name: "list override",
+			args: []string{"-config", path, "-sut_addresses", "10.0.0.2,10.0.0.3"},
+			check: func(c *types.Config) bool {
+				return slices.Equal(c.SutAddresses, []string{"10.0.0.2", "10.0.0.3"})
+			},
+		},
+		{
+			name:  "overrides are validated",
+			args:  []string{"-config", path, "-sut_mode", "fake", "-sut_addresses", "127.0.0.1"},
+			check: func(c *types.Config) bool { return c.SutMode == "fake" && c.LocalAddress == "127.0.0.1" },
+		},
+		{
+			name:        "invalid int",
+			args:        []string{"-config", path, "-sut_port_write", "many"},
+			wantLoadErr: "-sut_port_write",
+		},
+		{
+			name:        "invalid override",
+			args:        []string{"-config", path, "-sut_mode", "fake"},
+			wantLoadErr: "sut_mode",
+		},
+		{
+			name:    "positional argument",
+			args:    []string{"-config", path, "extra"},
+			wantErr: errUsage,
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"-config", path, "-unknown", "1"},
+			wantErr: errUsage,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := newFlagSet("test", "Test")
+			fs.SetOutput(&strings.Builder{})
+			loadConfig := addConfigFlags(fs)
+			if err := parseFlags(fs, test.args); err != nil || test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("parsing %v returned %v, want %v", test.args, err, test.wantErr)
+				}
+				return
+			}
+
+			config, err := loadConfig()
+			if test.wantLoadErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantLoadErr) {
+					t.Errorf("got error %v, want one containing %q", err, test.wantLoadErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !test.check(config) {
+				t.Errorf("unexpected config %+v", config)
+			}
+		})
+	}
+}
+
+func TestExperimentFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want func() types.Experiment
+	}{
+		{
+			name: "defaults",
+			want: types.DefaultExperiment,
+		},
+		{
+			name: "parameters and run id",
+			args: []string{"-k", "10", "-l", "2", "-parallelism", "2", "-run_id", "3"},
+			want: func() types.Experiment {
+				e := types.DefaultExperiment()
+				e.K, e.L, e.Parallelism, e.RunId = 10, 2, 2, 3
+				return e
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := newFlagSet("test", "Test")
+			experimentFromFlags := addExperimentFlags(fs)
+			if err := parseFlags(fs, test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := experimentFromFlags(), test.want(); got.ToFileName() != want.ToFileName() {
+				t.Errorf("got experiment %s, want %s", got.ToFileName(), want.ToFileName())
+			}
+		})
+	}
+}
+
+func TestInterruptContext(t *testing.T) {
+	ctx := interruptContext()
+	if ctx.Err() != nil {
+		t.Fatalf("context cancelled before a signal: %v", context.Cause(ctx))
+	}
+
+	// the first signal only cancels the context, the second one would exit
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled after SIGTERM")
+	}
+	if cause := context.Cause(ctx); cause == nil || !strings.Contains(cause.Error(), "terminated") {
+		t.Errorf("got cause %v, want the signal", cause)
+	}
+}
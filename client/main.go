@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net"
 	"os"
-	"prinkbenchmarking/src/config"
+	cfg "prinkbenchmarking/src/config"
 	"prinkbenchmarking/src/evaluation"
-	"prinkbenchmarking/src/exporter"
+	"prinkbenchmarking/src/failure"
+	"prinkbenchmarking/src/ports"
 	"prinkbenchmarking/src/types"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 func experimentDone() {
@@ -21,45 +27,6 @@ func experimentDone() {
 	defer file.Close()
 }
 
-func getExperiments() []types.Experiment {
-	// k = [5,10,20,40,80]
-	// delta = [1250,5000,20000,80000]
-	// l diversity = [0,2,4,8] (wenn null bedeutet, dass l diversity nicht beachtet wird)
-	// beta (active clusters). Damit das keinen Einfluss hat, so hoch setzen wie Daten: beta= 321728
-	// mu= 100 (wie im original paper)
-
-	k := []int{5, 10, 20, 40, 80}
-	// 20000, 80000 take too long
-	delta := []int{1250, 5000, 20000}
-	l := []int{0, 2, 4, 8}
-	beta := []int{321728}
-	mu := []int{100}
-
-	experiments := []types.Experiment{}
-
-	for _, k := range k {
-		for _, delta := range delta {
-			for _, l := range l {
-				for _, beta := range beta {
-					for _, mu := range mu {
-						experiment := types.Experiment{
-							K:     k,
-							Delta: delta,
-							L:     l,
-							Beta:  beta,
-							Zeta:  0,
-							Mu:    mu,
-						}
-						experiments = append(experiments, experiment)
-					}
-				}
-			}
-		}
-	}
-
-	return experiments
-}
-
 // Get preferred outbound ip of this machine
 func GetOutboundIP() net.IP {
 	conn, err := net.Dial("udp", "8.8.8.8:80")
@@ -74,153 +41,281 @@ func GetOutboundIP() net.IP {
 }
 
 func main() {
-	// Load the config
-	config := config.LoadConfig()
-
-	localIP := config.LocalAddress
-	if localIP == "" {
-		localIP = GetOutboundIP().String()
-	}
-
-	log.Printf("Local IP: %s", localIP)
-
-	// Start Prometheus exporter and register metrics
-	go exporter.StartPrometheusExporter(config.PrometheusExporterAddress)
-
-	if len(os.Args) > 1 {
-		if os.Args[1] == "listen" {
-			// just listen
-			experiment := types.Experiment{
-				K:            5,
-				Delta:        20000,
-				L:            0,
-				Beta:         321728,
-				Zeta:         0,
-				Mu:           100,
-				LocalHost:    localIP,
-				SutHost:      config.SutAddresses[0],
-				SutPortWrite: config.PortWrite,
-				SutPortRead:  config.PortRead,
-				RunId:        0,
-			}
-
-			evaluation.RunSockets(&experiment, *config)
-			return
+	if err := runCommand(os.Args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
 		}
+		log.Fatal(err)
 	}
+}
 
-	// Experiment: k=80, delta=20000, l=0, beta=321728, zeta=0, mu=100, run_id=2, local_host=10.0.53.197, sut_host=10.0.34.49, sut_port_write=50069, sut_port_read=50070
-
-	if len(os.Args) >= 3 {
-		// Run a single experiment
-		K, _ := strconv.Atoi(os.Args[1])
-		Delta, _ := strconv.Atoi(os.Args[2])
-		L, _ := strconv.Atoi(os.Args[3])
-
-		experiment := types.Experiment{
-			K:            K,
-			Delta:        Delta,
-			L:            L,
-			Beta: 321728,
-			Zeta: 0,
-			Mu:   100,
-			LocalHost: localIP,
-			SutHost: config.SutAddresses[0],
-			SutPortWrite: config.PortWrite,
-			SutPortRead: config.PortRead,
-			RunId: 0,
+// SetPrometheusTargets writes the metrics ports of the running experiments into the Prometheus target files.
+func SetPrometheusTargets(experiments []types.Experiment) {
+	targets := map[string][]string{"jobmanager": {}, "taskmanager": {}}
+	for _, experiment := range experiments {
+		// the taskmanagers of an experiment use consecutive ports
+		taskManagerPorts := []int{}
+		for i := 0; i < experiment.TaskManagers; i++ {
+			taskManagerPorts = append(taskManagerPorts, experiment.TaskManagerMetricsPort+i)
 		}
-		log.Print("Running in one-experiment mode")
 
-		StartExperiments(localIP, config, []types.Experiment{experiment})
+		for target, ports := range map[string][]int{"jobmanager": {experiment.JobManagerMetricsPort}, "taskmanager": taskManagerPorts} {
+			for _, port := range ports {
+				address := net.JoinHostPort(experiment.SutHost, strconv.Itoa(port))
+				targets[target] = append(targets[target], `{"targets":["`+address+`"],"labels":{"instance":"`+address+`","job":"prink","experiment":"`+experiment.ToFileName()+`"}}`)
+			}
+		}
 	}
 
+	for target, entries := range targets {
+		err := os.WriteFile("./prometheus/targets-"+target+".json", []byte("["+strings.Join(entries, ",")+"]"), 0666)
+		if err != nil {
+			log.Fatalf("Could not write targets.json: %v", err)
+		}
+	}
+}
 
-	StartExperiments(localIP, config, nil)
+// runningExperiments keeps the Prometheus targets in sync with the experiments running at the moment.
+type runningExperiments struct {
+	mutex       sync.Mutex
+	experiments map[string]types.Experiment
+}
 
-	experimentDone()
-	log.Printf("Created output files in: %s", config.OutputFolder)
+func (r *runningExperiments) add(experiment types.Experiment) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.experiments[experiment.ToFileName()] = experiment
+	r.setTargets()
 }
 
-func SetPrometheusTargets(addresses []string) {
-	// Set the prometheus targets
-	for target, port := range map[string]string{"jobmanager": "9249", "taskmanager": "9250"} {
-		targets := ""
-		for i, address := range addresses {
-			targets += `{"targets":["` + address + `:` + port + `"],"labels":{"instance":"` + address + `:` + port + `","job":"prink"}}`
-			if i < len(addresses)-1 {
-				targets += ","
-			}
-		}
-		targets = "[" + targets + "]"
-		err := os.WriteFile("./prometheus/targets-"+target+".json", []byte(targets), 0666)
-		if err != nil {
-			log.Fatalf("Could not write targets.json: %v", err)
-		}
+func (r *runningExperiments) remove(experiment types.Experiment) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.experiments, experiment.ToFileName())
+	r.setTargets()
+}
+
+func (r *runningExperiments) setTargets() {
+	experiments := []types.Experiment{}
+	for _, experiment := range r.experiments {
+		experiments = append(experiments, experiment)
+	}
+	SetPrometheusTargets(experiments)
+}
+
+// allocatePorts assigns free ports to the sockets, the REST API and the metrics of the experiment.
+// Only the sockets are bound on this machine and can be probed, the other ports are bound on the SUT host.
+// The returned function releases them once the experiment is done.
+func allocatePorts(allocator *ports.Allocator, experiment *types.Experiment) (func(), error) {
+	sockets, err := allocator.Allocate(2, true)
+	if err != nil {
+		return nil, err
 	}
+	// REST API, jobmanager metrics and the metrics of every taskmanager
+	n := sutPorts(experiment)
+	first, err := allocator.Allocate(n, false)
+	if err != nil {
+		allocator.Release(sockets, 2)
+		return nil, err
+	}
+
+	experiment.SutPortWrite = sockets
+	experiment.SutPortRead = sockets + 1
+	experiment.RestPort = first
+	experiment.JobManagerMetricsPort = first + 1
+	experiment.TaskManagerMetricsPort = first + 2
+	return func() {
+		allocator.Release(sockets, 2)
+		allocator.Release(first, n)
+	}, nil
+}
+
+// sutPorts returns the number of ports the experiment binds on the SUT host, starting at its RestPort.
+func sutPorts(experiment *types.Experiment) int {
+	return 2 + experiment.TaskManagers
 }
 
-func StartExperiments(localIP string, config *types.Config, experiments []types.Experiment) {
+// StartExperiments runs the experiments, or those of the campaign which are not done yet if experiments is nil,
+// on the SUT addresses until all are done or the context is cancelled.
+// Failed experiments are retried according to config.Retry and their failures recorded in failures.json.
+// The state of the campaign is written to campaign_status.json in the output folder in both cases.
+func StartExperiments(ctx context.Context, localIP string, config *types.Config, experiments []types.Experiment) {
 	wg := sync.WaitGroup{}
+	start := time.Now()
 
 	addresses := config.SutAddresses
 	log.Println("Starting experiments on: ", addresses)
 
-	// add addresses to targets.json
-	SetPrometheusTargets(addresses)
+	journal, err := evaluation.OpenJournal(config.OutputFolder)
+	if err != nil {
+		log.Fatalf("Could not open journal: %v", err)
+	}
+	failures, err := evaluation.OpenFailureLog(config.OutputFolder)
+	if err != nil {
+		log.Fatalf("Could not open failure log: %v", err)
+	}
 
+	// all experiments of the campaign, for its status
+	campaign := experiments
 	if experiments == nil {
-		experiments = []types.Experiment{}
-		for run := 0; run < 3; run++ {
-			toAdd := []types.Experiment{}
-			for _, e := range getExperiments() {
-				e.RunId = run
-				toAdd = append(toAdd, e)
-			}
-			experiments = append(experiments, toAdd...)
+		experiments, err = cfg.LoadExperiments(config)
+		if err != nil {
+			log.Fatalf("Could not load experiments: %v", err)
+		}
+
+		// skip the experiments a previous client already completed
+		total := len(experiments)
+		campaign = experiments
+		experiments, err = journal.Resume(experiments, config.Retry)
+		if err != nil {
+			log.Fatalf("Could not update journal: %v", err)
+		}
+		if len(experiments) < total {
+			log.Printf("Resuming campaign: %d of %d experiments left", len(experiments), total)
+		}
+	} else {
+		// explicit experiments are run even if they succeeded before, but continue counting their tries
+		for i := range experiments {
+			experiments[i].Try = journal.Entry(experiments[i]).Tries
 		}
 	}
 
+	// the ports are assigned per experiment, so several experiments can run on the same Docker host
+	first := config.PortRange.Start
+	if first == 0 {
+		first = config.PortWrite
+	}
+	if first <= 0 || first > config.PortRange.End {
+		log.Fatalf("Invalid port range %d-%d", first, config.PortRange.End)
+	}
+	allocator := ports.NewAllocator(first, config.PortRange.End)
+	running := &runningExperiments{experiments: map[string]types.Experiment{}}
+	SetPrometheusTargets(nil)
+
 	exp := make(chan types.Experiment, len(experiments))
 	for _, experiment := range experiments {
 		exp <- experiment
 	}
 
-	wg.Add(len(addresses))
+	// the experiments which are queued or wait for a retry, the workers stop once all are done
+	left := atomic.Int64{}
+	left.Store(int64(len(experiments)))
+	done := make(chan bool)
+	if len(experiments) == 0 {
+		close(done)
+	}
+	finished := func() {
+		if left.Add(-1) == 0 {
+			close(done)
+		}
+	}
+
+	// retry records the failed try and queues the experiment again after the backoff of its failure class,
+	// unless it was tried as often as the class allows
+	retry := func(experiment types.Experiment, start time.Time, err error) {
+		class := failure.ClassOf(err)
+		policy := config.Retry.Policy(string(class))
+		tries := experiment.Try + 1
+		retried := tries < policy.MaxTries
+		backoff := policy.Delay(tries)
+
+		if err := failures.Add(experiment, start, err, retried, backoff); err != nil {
+			log.Printf("Could not update failure log: %v", err)
+		}
+		if !retried {
+			log.Printf("Experiment %v failed %d times, last with %s. Skipping it.", experiment, tries, class)
+			finished()
+			return
+		}
 
-	for i, sutHost := range addresses {
-		go func(i int, sutHost string) {
-			for len(exp) > 0 {
-				experiment := <-exp
+		log.Printf("Retrying experiment %v in %v", experiment.ToFileName(), backoff)
+		experiment.Try = tries
+		time.AfterFunc(backoff, func() { exp <- experiment })
+	}
 
-				if experiment.Try >= 3 {
-					log.Printf("Experiment %v failed 3 times. Skipping it.", experiment)
-					continue
+	wg.Add(len(addresses))
+
+	for _, sutHost := range addresses {
+		go func(sutHost string) {
+			defer wg.Done()
+			for {
+				var experiment types.Experiment
+				select {
+				case experiment = <-exp:
+				case <-done:
+					return
+				// no new experiments are started once the client shuts down
+				case <-ctx.Done():
+					return
 				}
+				start := time.Now()
 
 				experiment.LocalHost = localIP
 				experiment.SutHost = sutHost
-				experiment.SutPortWrite = config.PortWrite + 2*i
-				experiment.SutPortRead = config.PortRead + 2*i
+				if err := journal.Start(experiment); err != nil {
+					log.Printf("Could not update journal: %v", err)
+				}
+
+				// failing to allocate ports counts as a failed try like any other
+				release, err := allocatePorts(allocator, &experiment)
+				if err != nil {
+					err = failure.Errorf(failure.PortConflict, "could not allocate ports: %v", err)
+					log.Printf("Experiment %v failed: %v", experiment, err)
+					if err := journal.Finish(experiment, err); err != nil {
+						log.Printf("Could not update journal: %v", err)
+					}
+					retry(experiment, start, err)
+					continue
+				}
+				running.add(experiment)
+
 				// Start the experiment
 				log.Printf("Starting %d experiment on %s: %v", len(exp), sutHost, experiment)
 
-				if evaluation.RunExperiment(experiment, *config) {
+				err = evaluation.RunExperiment(ctx, experiment, *config)
+				running.remove(experiment)
+				if failure.ClassOf(err) == failure.PortConflict {
+					// the ports are bound by another program on this machine or the SUT host, the retry gets other ones
+					allocator.Block(experiment.SutPortWrite, 2)
+					allocator.Block(experiment.RestPort, sutPorts(&experiment))
+				}
+				release()
+				if ctx.Err() != nil {
+					log.Printf("Experiment %v interrupted", experiment)
+					if err := journal.Interrupt(experiment); err != nil {
+						log.Printf("Could not update journal: %v", err)
+					}
+					return
+				}
+				if err := journal.Finish(experiment, err); err != nil {
+					log.Printf("Could not update journal: %v", err)
+				}
+
+				if err == nil {
 					log.Printf("Experiment %v finished successfully", experiment)
+					finished()
 				} else {
-					log.Printf("Experiment %v failed", experiment)
-					// retry the experiment
-					experiment.Try += 1
-					exp <- experiment
+					log.Printf("Experiment %v failed: %v", experiment, err)
+					retry(experiment, start, err)
 				}
 			}
-			defer wg.Done()
-		}(i, sutHost)
+		}(sutHost)
 	}
 
 	wg.Wait()
 
-	if len(exp) > 0 {
-		log.Printf("There are %d experiments left to run", len(exp))
+	if left.Load() > 0 {
+		log.Printf("There are %d experiments left to run", left.Load())
+	}
+
+	status := evaluation.NewCampaignStatus(journal, campaign, config.Retry)
+	status.Campaign = config.CampaignID
+	status.Start = start
+	if ctx.Err() != nil {
+		status.State = evaluation.CampaignInterrupted
+		status.Reason = context.Cause(ctx).Error()
+	}
+	if err := status.Save(config.OutputFolder); err != nil {
+		log.Printf("Could not write campaign status: %v", err)
 	}
 }
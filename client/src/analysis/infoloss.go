@@ -0,0 +1,172 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"prinkbenchmarking/src/types"
+	"sort"
+	"time"
+)
+
+// infoLossSeries accumulates the information loss per second of an experiment run.
+type infoLossSeries struct {
+	start time.Time
+	sums  []float64
+	count []int
+}
+
+func (s *infoLossSeries) add(received time.Time, infoLoss float64) {
+	if s.start.IsZero() {
+		s.start = received
+	}
+	second := int(received.Sub(s.start) / time.Second)
+	if second < 0 {
+		second = 0
+	}
+	for len(s.sums) <= second {
+		s.sums = append(s.sums, 0)
+		s.count = append(s.count, 0)
+	}
+	s.sums[second] += infoLoss
+	s.count[second]++
+}
+
+// InfoLossSecond is the mean information loss of the records received in one second of a run.
+type InfoLossSecond struct {
+	Second  int     `json:"second"`
+	Records int     `json:"records"`
+	Mean    float64 `json:"mean"`
+}
+
+func (s *infoLossSeries) seconds() []InfoLossSecond {
+	seconds := make([]InfoLossSecond, len(s.sums))
+	for i := range s.sums {
+		seconds[i] = InfoLossSecond{Second: i, Records: s.count[i]}
+		if s.count[i] > 0 {
+			seconds[i].Mean = s.sums[i] / float64(s.count[i])
+		}
+	}
+	return seconds
+}
+
+// SaveInfoLoss writes the information loss over time of every run and the privacy/utility
+// trade-off across the experiment parameters into the analysis folder.
+func SaveInfoLoss(folder string, summaries []Summary) error {
+	if err := os.MkdirAll(folder+"/info_loss", os.ModePerm); err != nil {
+		return fmt.Errorf("could not create analysis directory: %v", err)
+	}
+
+	for _, s := range summaries {
+		if err := writeCSV(folder+"/info_loss/"+s.Experiment.ToFileName()+".csv", []string{"second", "records", "info_loss_mean"}, func(write func([]string) error) error {
+			for _, second := range s.InfoLossOverTime {
+				if err := write([]string{fmt.Sprintf("%d", second.Second), fmt.Sprintf("%d", second.Records), formatFloat(second.Mean)}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return saveTradeoff(folder+"/tradeoff.csv", summaries)
+}
+
+type tradeoffPoint struct {
+	experiment types.Experiment
+	runs       int
+	// runs whose output had an info_loss column
+	infoLossRuns int
+	infoLoss     float64
+	latencyP50   float64
+	latencyP99   float64
+	throughput   float64
+}
+
+// saveTradeoff writes the means over all runs of the same parameters,
+// so info loss can be plotted against latency and throughput for k, delta and l.
+func saveTradeoff(path string, summaries []Summary) error {
+	points := map[string]*tradeoffPoint{}
+	for _, s := range summaries {
+		e := s.Experiment
+		e.RunId = 0
+		key := e.ToFileName()
+
+		point, ok := points[key]
+		if !ok {
+			point = &tradeoffPoint{experiment: e}
+			points[key] = point
+		}
+		point.runs++
+		if s.InfoLoss.Count > 0 {
+			point.infoLossRuns++
+			point.infoLoss += s.InfoLoss.Mean
+		}
+		point.latencyP50 += s.Latency.P50
+		point.latencyP99 += s.Latency.P99
+		point.throughput += s.Throughput
+	}
+
+	parameters := types.ExperimentParameters()
+
+	// ordered numerically by the parameters, so k=5 comes before k=10
+	sorted := make([]*tradeoffPoint, 0, len(points))
+	for _, point := range points {
+		sorted = append(sorted, point)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		for _, name := range parameters {
+			a, _ := sorted[i].experiment.Parameter(name)
+			b, _ := sorted[j].experiment.Parameter(name)
+			if *a != *b {
+				return *a < *b
+			}
+		}
+		return false
+	})
+	header := append(append([]string{}, parameters...), "runs", "info_loss_mean", "latency_p50_ms", "latency_p99_ms", "throughput")
+
+	return writeCSV(path, header, func(write func([]string) error) error {
+		for _, point := range sorted {
+			runs := float64(point.runs)
+			infoLoss := ""
+			if point.infoLossRuns > 0 {
+				infoLoss = formatFloat(point.infoLoss / float64(point.infoLossRuns))
+			}
+
+			row := []string{}
+			for _, name := range parameters {
+				field, _ := point.experiment.Parameter(name)
+				row = append(row, fmt.Sprintf("%d", *field))
+			}
+			row = append(row, fmt.Sprintf("%d", point.runs), infoLoss,
+				formatFloat(point.latencyP50/runs), formatFloat(point.latencyP99/runs), formatFloat(point.throughput/runs))
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeCSV writes a semicolon separated file with the given header and the rows passed to write by rows.
+func writeCSV(path string, header []string, rows func(write func([]string) error) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Comma = ';'
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := rows(writer.Write); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
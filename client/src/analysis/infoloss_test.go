@@ -0,0 +1,146 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"os"
+	"prinkbenchmarking/src/types"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInfoLossSeries(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type sample struct {
+		offset   time.Duration
+		infoLoss float64
+	}
+
+	tests := []struct {
+		name    string
+		samples []sample
+		want    []InfoLossSecond
+	}{
+		{name: "no records", want: []InfoLossSecond{}},
+		{
+			name:    "mean per second",
+			samples: []sample{{0, 0.2}, {500 * time.Millisecond, 0.4}, {1500 * time.Millisecond, 0.5}},
+			want:    []InfoLossSecond{{Second: 0, Records: 2, Mean: 0.3}, {Second: 1, Records: 1, Mean: 0.5}},
+		},
+		{
+			name:    "seconds without records",
+			samples: []sample{{0, 0.1}, {2 * time.Second, 0.3}},
+			want:    []InfoLossSecond{{Second: 0, Records: 1, Mean: 0.1}, {Second: 1}, {Second: 2, Records: 1, Mean: 0.3}},
+		},
+		{
+			name:    "records received before the first one count to the first second",
+			samples: []sample{{time.Second, 0.2}, {0, 0.4}},
+			want:    []InfoLossSecond{{Second: 0, Records: 2, Mean: 0.3}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			series := &infoLossSeries{}
+			for _, s := range test.samples {
+				series.add(start.Add(s.offset), s.infoLoss)
+			}
+
+			got := series.seconds()
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d seconds %v, want %v", len(got), got, test.want)
+			}
+			for i := range got {
+				if got[i].Second != test.want[i].Second || got[i].Records != test.want[i].Records || formatFloat(got[i].Mean) != formatFloat(test.want[i].Mean) {
+					t.Errorf("second %d is %+v, want %+v", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSaveTradeoff(t *testing.T) {
+	summary := func(k, run int, infoLoss float64, p50 float64) Summary {
+		e := types.DefaultExperiment()
+		e.K = k
+		e.RunId = run
+		s := Summary{Experiment: e, Latency: Distribution{P50: p50}, Throughput: 100}
+		if infoLoss >= 0 {
+			s.InfoLoss = Distribution{Count: 10, Mean: infoLoss}
+		}
+		return s
+	}
+
+	tests := []struct {
+		name      string
+		summaries []Summary
+		// k, runs, info_loss_mean and latency_p50_ms of the rows
+		want [][]string
+	}{
+		{
+			name:      "runs are averaged",
+			summaries: []Summary{summary(5, 0, 0.2, 10), summary(5, 1, 0.4, 30)},
+			want:      [][]string{{"5", "2", "0.300", "20.000"}},
+		},
+		{
+			name:      "ordered numerically",
+			summaries: []Summary{summary(10, 0, 0.5, 10), summary(5, 0, 0.2, 10)},
+			want:      [][]string{{"5", "1", "0.200", "10.000"}, {"10", "1", "0.500", "10.000"}},
+		},
+		{
+			name:      "only runs with info loss count to its mean",
+			summaries: []Summary{summary(5, 0, 0.2, 10), summary(5, 1, -1, 10)},
+			want:      [][]string{{"5", "2", "0.200", "10.000"}},
+		},
+		{
+			name:      "info loss is empty without any run having it",
+			summaries: []Summary{summary(5, 0, -1, 10)},
+			want:      [][]string{{"5", "1", "", "10.000"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := t.TempDir() + "/tradeoff.csv"
+			if err := saveTradeoff(path, test.summaries); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			reader := csv.NewReader(file)
+			reader.Comma = ';'
+			rows, err := reader.ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			header := rows[0]
+			columns := []int{}
+			for _, name := range []string{"k", "runs", "info_loss_mean", "latency_p50_ms"} {
+				columns = append(columns, slices.Index(header, name))
+			}
+			got := [][]string{}
+			for _, row := range rows[1:] {
+				selected := []string{}
+				for _, column := range columns {
+					selected = append(selected, row[column])
+				}
+				got = append(got, selected)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got rows %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if strings.Join(got[i], ";") != strings.Join(test.want[i], ";") {
+					t.Errorf("row %d is %v, want %v", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"prinkbenchmarking/src/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ResultFile is a results file written by the client for one experiment run.
+type ResultFile struct {
+	Path       string
+	Created    time.Time
+	Experiment types.Experiment
+}
+
+// Header maps the column names of a results file to their index.
+type Header map[string]int
+
+func NewHeader(columns []string) Header {
+	header := Header{}
+	for i, column := range columns {
+		header[strings.TrimSpace(column)] = i
+	}
+	return header
+}
+
+// Record returns the record with the given fields.
+func (h Header) Record(fields []string) Record {
+	return Record{header: h, fields: fields}
+}
+
+// Record is a line of a results file.
+type Record struct {
+	header Header
+	fields []string
+}
+
+// Get returns the value of the given column, or "" if the record does not have it.
+func (r Record) Get(column string) string {
+	i, ok := r.header[column]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return r.fields[i]
+}
+
+// Time returns the value of the given column parsed as a time written by the client.
+func (r Record) Time(column string) (time.Time, error) {
+	return ParseTime(r.Get(column))
+}
+
+// ParseTime parses a time formatted with %v, stripping the monotonic clock reading if present.
+func ParseTime(value string) (time.Time, error) {
+	if i := strings.Index(value, " m="); i >= 0 {
+		value = value[:i]
+	}
+	return time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", value)
+}
+
+// FindResultFiles returns the results files in the output folder.
+// If an experiment was run several times, e.g. because earlier tries failed, only the latest file is returned.
+func FindResultFiles(folder string) ([]ResultFile, error) {
+	latest := map[string]ResultFile{}
+
+	err := filepath.WalkDir(folder, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasPrefix(d.Name(), "results.") || !strings.HasSuffix(d.Name(), ".csv") {
+			return nil
+		}
+
+		// results.<created>.<experiment>.csv
+		parts := strings.Split(d.Name(), ".")
+		if len(parts) != 4 {
+			return nil
+		}
+		created, err := time.ParseInLocation("2006-01-02_15:04:05", parts[1], time.Local)
+		if err != nil {
+			return nil
+		}
+		experiment, err := types.ParseFileName(parts[2])
+		if err != nil {
+			return nil
+		}
+
+		file := ResultFile{Path: path, Created: created, Experiment: experiment}
+		if previous, ok := latest[parts[2]]; !ok || previous.Created.Before(created) {
+			latest[parts[2]] = file
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]ResultFile, 0, len(latest))
+	for _, file := range latest {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Experiment.ToFileName() < files[j].Experiment.ToFileName()
+	})
+
+	return files, nil
+}
+
+// ReadResults calls handle for every record of the results file.
+func ReadResults(path string, handle func(record Record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	if !scanner.Scan() {
+		return fmt.Errorf("%s has no header", path)
+	}
+	header := NewHeader(strings.Split(scanner.Text(), ";"))
+
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ";")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if err := handle(header.Record(fields)); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
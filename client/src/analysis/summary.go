@@ -0,0 +1,222 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"prinkbenchmarking/src/types"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Distribution summarizes a set of observations.
+type Distribution struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	P999  float64 `json:"p99_9"`
+	Max   float64 `json:"max"`
+}
+
+// NewDistribution returns the distribution of the values. The values are sorted in place.
+func NewDistribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return Distribution{
+		Count: len(values),
+		Mean:  sum / float64(len(values)),
+		P50:   percentile(values, 0.5),
+		P90:   percentile(values, 0.9),
+		P99:   percentile(values, 0.99),
+		P999:  percentile(values, 0.999),
+		Max:   values[len(values)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of the sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// Summary holds the results of one experiment run.
+type Summary struct {
+	Experiment types.Experiment `json:"-"`
+	Parameters map[string]int   `json:"experiment"`
+	File       string           `json:"file"`
+
+	Records int `json:"records"`
+	// Duration from the first record sent to the last record received in seconds
+	Duration float64 `json:"duration_s"`
+	// Throughput in records per second received over the duration
+	Throughput float64 `json:"throughput"`
+	// End-to-end latency (t_e - t_s) in milliseconds
+	Latency Distribution `json:"latency_ms"`
+
+	// Equivalence classes and the number of classes violating k-anonymity and l-diversity
+	Classes     int `json:"classes"`
+	KViolations int `json:"k_violations"`
+	LViolations int `json:"l_violations"`
+
+	// Information loss of the anonymized records, overall and per second of the run
+	InfoLoss         Distribution     `json:"info_loss"`
+	InfoLossOverTime []InfoLossSecond `json:"-"`
+}
+
+func experimentParameters(e types.Experiment) map[string]int {
+	parameters := map[string]int{}
+	for _, name := range types.ExperimentParameters() {
+		field, _ := e.Parameter(name)
+		parameters[name] = *field
+	}
+	parameters["run_id"] = e.RunId
+	return parameters
+}
+
+// AnalyzeFile computes the summary of a results file.
+func AnalyzeFile(file ResultFile, config types.Config) (Summary, error) {
+	summary := Summary{
+		Experiment: file.Experiment,
+		Parameters: experimentParameters(file.Experiment),
+		File:       file.Path,
+	}
+
+	var first, last time.Time
+	latencies := []float64{}
+	infoLosses := []float64{}
+	infoLossOverTime := &infoLossSeries{}
+	verifier := NewVerifier(file.Experiment, config.Schema)
+
+	err := ReadResults(file.Path, func(record Record) error {
+		verifier.Add(record)
+
+		// results without a phase column predate the measurement window and are analyzed completely
+		if phase := record.Get("phase"); phase != "" && phase != types.PhaseMeasurement {
+			return nil
+		}
+
+		received, err := record.Time("t_e")
+		if err != nil {
+			return nil
+		}
+		sent, err := record.Time("t_s")
+		if err != nil {
+			return nil
+		}
+
+		if infoLoss, err := strconv.ParseFloat(record.Get("info_loss"), 64); err == nil {
+			infoLosses = append(infoLosses, infoLoss)
+			infoLossOverTime.add(received, infoLoss)
+		}
+
+		latencies = append(latencies, float64(received.Sub(sent).Microseconds())/1000)
+		if first.IsZero() || sent.Before(first) {
+			first = sent
+		}
+		if received.After(last) {
+			last = received
+		}
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	summary.Records = len(latencies)
+	summary.Latency = NewDistribution(latencies)
+	summary.InfoLoss = NewDistribution(infoLosses)
+	summary.InfoLossOverTime = infoLossOverTime.seconds()
+	if summary.Records > 0 {
+		summary.Duration = last.Sub(first).Seconds()
+		if summary.Duration > 0 {
+			summary.Throughput = float64(summary.Records) / summary.Duration
+		}
+	}
+
+	report := verifier.Report()
+	summary.Classes = report.Classes
+	summary.KViolations = len(report.KViolations)
+	summary.LViolations = len(report.LViolations)
+
+	return summary, nil
+}
+
+// Analyze computes the summaries of all results files in the output folder.
+func Analyze(config types.Config) ([]Summary, error) {
+	files, err := FindResultFiles(config.OutputFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []Summary{}
+	for _, file := range files {
+		summary, err := AnalyzeFile(file, config)
+		if err != nil {
+			log.Printf("Could not analyze %s: %v", file.Path, err)
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// SaveSummaries writes the summaries as summary.csv and summary.json into the analysis folder.
+func SaveSummaries(folder string, summaries []Summary) error {
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return fmt.Errorf("could not create analysis directory: %v", err)
+	}
+
+	jsonFile, err := os.Create(folder + "/summary.json")
+	if err != nil {
+		return err
+	}
+	defer jsonFile.Close()
+
+	encoder := json.NewEncoder(jsonFile)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(summaries); err != nil {
+		return err
+	}
+
+	header := append(types.ExperimentKeys(), "records", "duration_s", "throughput",
+		"latency_mean_ms", "latency_p50_ms", "latency_p90_ms", "latency_p99_ms", "latency_p99_9_ms", "latency_max_ms",
+		"classes", "k_violations", "l_violations",
+		"info_loss_mean", "info_loss_p50", "info_loss_p90", "info_loss_p99", "info_loss_max")
+
+	return writeCSV(folder+"/summary.csv", header, func(write func([]string) error) error {
+		for _, s := range summaries {
+			row := append(s.Experiment.ToLabels(),
+				fmt.Sprintf("%d", s.Records), formatFloat(s.Duration), formatFloat(s.Throughput),
+				formatFloat(s.Latency.Mean), formatFloat(s.Latency.P50), formatFloat(s.Latency.P90),
+				formatFloat(s.Latency.P99), formatFloat(s.Latency.P999), formatFloat(s.Latency.Max),
+				fmt.Sprintf("%d", s.Classes), fmt.Sprintf("%d", s.KViolations), fmt.Sprintf("%d", s.LViolations),
+				formatFloat(s.InfoLoss.Mean), formatFloat(s.InfoLoss.P50), formatFloat(s.InfoLoss.P90),
+				formatFloat(s.InfoLoss.P99), formatFloat(s.InfoLoss.Max))
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func formatFloat(value float64) string {
+	return fmt.Sprintf("%.3f", value)
+}
@@ -0,0 +1,164 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"prinkbenchmarking/src/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+type equivalenceClass struct {
+	records     int
+	individuals map[string]struct{}
+	sensitive   map[string]struct{}
+}
+
+// Verifier checks whether anonymized records satisfy k-anonymity and l-diversity.
+// Records are grouped into equivalence classes by their generalized quasi-identifiers.
+// Each class needs at least k distinct individuals and, if l > 0, at least l distinct sensitive values.
+// The columns are taken from the roles of the schema.
+type Verifier struct {
+	k                int
+	l                int
+	quasiIdentifiers []string
+	sensitive        string
+	id               string
+
+	records int
+	classes map[string]*equivalenceClass
+}
+
+func NewVerifier(experiment types.Experiment, schema types.Schema) *Verifier {
+	verifier := &Verifier{
+		k:                experiment.K,
+		l:                experiment.L,
+		quasiIdentifiers: schema.WithRole(types.RoleQuasiIdentifier),
+		classes:          map[string]*equivalenceClass{},
+	}
+	// without an id or sensitive column every record counts as another individual or value
+	if ids := schema.WithRole(types.RoleID); len(ids) > 0 {
+		verifier.id = ids[0]
+	}
+	if sensitive := schema.WithRole(types.RoleSensitive); len(sensitive) > 0 {
+		verifier.sensitive = sensitive[0]
+	}
+	return verifier
+}
+
+func (v *Verifier) Add(record Record) {
+	values := make([]string, len(v.quasiIdentifiers))
+	for i, column := range v.quasiIdentifiers {
+		values[i] = record.Get(column)
+	}
+	key := strings.Join(values, "\x1f")
+
+	class, ok := v.classes[key]
+	if !ok {
+		class = &equivalenceClass{individuals: map[string]struct{}{}, sensitive: map[string]struct{}{}}
+		v.classes[key] = class
+	}
+
+	class.records++
+	v.records++
+	class.individuals[v.value(record, v.id)] = struct{}{}
+	class.sensitive[v.value(record, v.sensitive)] = struct{}{}
+}
+
+// value returns the value of the column, or a value unique to the record if there is no such column.
+func (v *Verifier) value(record Record, column string) string {
+	if column == "" {
+		return fmt.Sprintf("#%d", v.records)
+	}
+	return record.Get(column)
+}
+
+// Violation is an equivalence class which is not k-anonymous or not l-diverse.
+type Violation struct {
+	QuasiIdentifiers map[string]string `json:"quasi_identifiers"`
+	Records          int               `json:"records"`
+	Individuals      int               `json:"individuals"`
+	SensitiveValues  int               `json:"sensitive_values"`
+}
+
+// VerificationReport lists the violations found in the output of one experiment.
+type VerificationReport struct {
+	K       int `json:"k"`
+	L       int `json:"l"`
+	Records int `json:"records"`
+	Classes int `json:"classes"`
+
+	KViolations []Violation `json:"k_violations"`
+	LViolations []Violation `json:"l_violations"`
+}
+
+func (r VerificationReport) String() string {
+	return fmt.Sprintf("%d records in %d equivalence classes, %d classes with less than k=%d individuals, %d classes with less than l=%d sensitive values",
+		r.Records, r.Classes, len(r.KViolations), r.K, len(r.LViolations), r.L)
+}
+
+func (v *Verifier) Report() VerificationReport {
+	report := VerificationReport{
+		K:           v.k,
+		L:           v.l,
+		Records:     v.records,
+		Classes:     len(v.classes),
+		KViolations: []Violation{},
+		LViolations: []Violation{},
+	}
+
+	keys := make([]string, 0, len(v.classes))
+	for key := range v.classes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		class := v.classes[key]
+		kViolated := len(class.individuals) < v.k
+		// l = 0 means that l-diversity is not enforced
+		lViolated := v.l > 0 && len(class.sensitive) < v.l
+		if !kViolated && !lViolated {
+			continue
+		}
+
+		violation := Violation{
+			QuasiIdentifiers: map[string]string{},
+			Records:          class.records,
+			Individuals:      len(class.individuals),
+			SensitiveValues:  len(class.sensitive),
+		}
+		for i, value := range strings.Split(key, "\x1f") {
+			violation.QuasiIdentifiers[v.quasiIdentifiers[i]] = value
+		}
+
+		if kViolated {
+			report.KViolations = append(report.KViolations, violation)
+		}
+		if lViolated {
+			report.LViolations = append(report.LViolations, violation)
+		}
+	}
+
+	return report
+}
+
+// SaveVerificationReport writes the report next to the results of the experiment.
+func SaveVerificationReport(path string, experiment *types.Experiment, report VerificationReport) error {
+	path = fmt.Sprintf("%s/%d", path, experiment.RunId)
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return fmt.Errorf("could not create output directory: %v", err)
+	}
+
+	file, err := os.Create(path + "/verification." + time.Now().Format("2006-01-02_15:04:05") + "." + experiment.ToFileName() + ".json")
+	if err != nil {
+		return fmt.Errorf("could not open verification file: %v", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
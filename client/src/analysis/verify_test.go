@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"prinkbenchmarking/src/types"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVerifier(t *testing.T) {
+	schema := types.Schema{Columns: []types.Column{
+		{Name: "id", Role: types.RoleID},
+		{Name: "zip", Role: types.RoleQuasiIdentifier},
+		{Name: "age", Role: types.RoleQuasiIdentifier},
+		{Name: "disease", Role: types.RoleSensitive},
+	}}
+	withoutID := types.Schema{Columns: schema.Columns[1:]}
+
+	tests := []struct {
+		name    string
+		schema  types.Schema
+		k, l    int
+		records []string
+		classes int
+		// records of the classes violating k and l
+		kViolations []int
+		lViolations []int
+	}{
+		{
+			name:    "k-anonymous",
+			schema:  schema,
+			k:       2,
+			records: []string{"1;(10,20);30;flu", "2;(10,20);30;flu", "3;40;(50,60);cold", "4;40;(50,60);cold"},
+			classes: 2,
+		},
+		{
+			name:    "class with less than k individuals",
+			schema:  schema,
+			k:       2,
+			records: []string{"1;(10,20);30;flu", "2;(10,20);30;flu", "3;40;(50,60);cold"},
+			classes: 2,
+			// the violation is found in the second class
+			kViolations: []int{1},
+		},
+		{
+			name:        "records of the same individual count once",
+			schema:      schema,
+			k:           2,
+			records:     []string{"1;(10,20);30;flu", "1;(10,20);30;cold"},
+			classes:     1,
+			kViolations: []int{2},
+		},
+		{
+			name:        "class with less than l sensitive values",
+			schema:      schema,
+			k:           2,
+			l:           2,
+			records:     []string{"1;(10,20);30;flu", "2;(10,20);30;flu", "3;40;(50,60);flu", "4;40;(50,60);cold"},
+			classes:     2,
+			lViolations: []int{2},
+		},
+		{
+			name:    "l of 0 is not enforced",
+			schema:  schema,
+			k:       2,
+			records: []string{"1;(10,20);30;flu", "2;(10,20);30;flu"},
+			classes: 1,
+		},
+		{
+			name:        "class violating k and l",
+			schema:      schema,
+			k:           3,
+			l:           2,
+			records:     []string{"1;(10,20);30;flu", "2;(10,20);30;flu"},
+			classes:     1,
+			kViolations: []int{2},
+			lViolations: []int{2},
+		},
+		{
+			name:    "without id every record is an individual",
+			schema:  withoutID,
+			k:       2,
+			records: []string{"(10,20);30;flu", "(10,20);30;flu"},
+			classes: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := NewVerifier(types.Experiment{K: test.k, L: test.l}, test.schema)
+			header := NewHeader(test.schema.Names())
+			for _, record := range test.records {
+				verifier.Add(header.Record(strings.Split(record, ";")))
+			}
+
+			report := verifier.Report()
+			if report.Records != len(test.records) || report.Classes != test.classes {
+				t.Errorf("got %d records in %d classes, want %d in %d", report.Records, report.Classes, len(test.records), test.classes)
+			}
+			if got := violationRecords(report.KViolations); !slices.Equal(got, test.kViolations) {
+				t.Errorf("got k violations of %v records, want %v", got, test.kViolations)
+			}
+			if got := violationRecords(report.LViolations); !slices.Equal(got, test.lViolations) {
+				t.Errorf("got l violations of %v records, want %v", got, test.lViolations)
+			}
+		})
+	}
+}
+
+func TestVerifierViolation(t *testing.T) {
+	schema := types.Schema{Columns: []types.Column{
+		{Name: "id", Role: types.RoleID},
+		{Name: "zip", Role: types.RoleQuasiIdentifier},
+		{Name: "disease", Role: types.RoleSensitive},
+	}}
+	verifier := NewVerifier(types.Experiment{K: 3, L: 3}, schema)
+	header := NewHeader(schema.Names())
+	verifier.Add(header.Record([]string{"1", "(10,20)", "flu"}))
+	verifier.Add(header.Record([]string{"1", "(10,20)", "cold"}))
+	verifier.Add(header.Record([]string{"2", "(10,20)", "flu"}))
+
+	report := verifier.Report()
+	want := Violation{QuasiIdentifiers: map[string]string{"zip": "(10,20)"}, Records: 3, Individuals: 2, SensitiveValues: 2}
+	if len(report.KViolations) != 1 {
+		t.Fatalf("got %d k violations, want 1", len(report.KViolations))
+	}
+	got := report.KViolations[0]
+	if got.QuasiIdentifiers["zip"] != want.QuasiIdentifiers["zip"] || got.Records != want.Records ||
+		got.Individuals != want.Individuals || got.SensitiveValues != want.SensitiveValues {
+		t.Errorf("got violation %+v, want %+v", got, want)
+	}
+}
+
+func violationRecords(violations []Violation) []int {
+	records := []int{}
+	for _, violation := range violations {
+		records = append(records, violation.Records)
+	}
+	return records
+}
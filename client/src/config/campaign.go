@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"prinkbenchmarking/src/types"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultCampaign returns the experiment matrix used if no campaign file is configured.
+func DefaultCampaign() *types.Campaign {
+	// k = [5,10,20,40,80]
+	// delta = [1250,5000,20000,80000]
+	// l diversity = [0,2,4,8] (wenn null bedeutet, dass l diversity nicht beachtet wird)
+	// beta (active clusters). Damit das keinen Einfluss hat, so hoch setzen wie Daten: beta= 321728
+	// mu= 100 (wie im original paper)
+	return &types.Campaign{
+		Repetitions: 3,
+		Dimensions: map[string][]int{
+			"k": {5, 10, 20, 40, 80},
+			// 80000 takes too long
+			"delta": {1250, 5000, 20000},
+			"l":     {0, 2, 4, 8},
+			"beta":  {321728},
+			"zeta":  {0},
+			"mu":    {100},
+		},
+	}
+}
+
+// LoadExperiments returns the experiments of the campaign referenced in the config.
+func LoadExperiments(config *types.Config) ([]types.Experiment, error) {
+	campaign := DefaultCampaign()
+	if config.Campaign != "" {
+		var err error
+		campaign, err = ReadCampaignFromFile(config.Campaign)
+		if err != nil {
+			return nil, fmt.Errorf("could not load campaign %s: %v", config.Campaign, err)
+		}
+	}
+
+	return ExpandCampaign(campaign)
+}
+
+// ReadCampaignFromFile returns the decoded and validated campaign stored at campaignPath.
+// Both YAML and JSON files are accepted.
+func ReadCampaignFromFile(campaignPath string) (*types.Campaign, error) {
+	file, err := os.Open(campaignPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadCampaign(file)
+}
+
+func ReadCampaign(reader io.Reader) (*types.Campaign, error) {
+	d := yaml.NewDecoder(reader)
+	// reject unknown keys instead of silently ignoring typos
+	d.SetStrict(true)
+
+	// keys which are omitted keep these values
+	campaign := types.Campaign{Repetitions: 1}
+	if err := d.Decode(&campaign); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateCampaign(&campaign); err != nil {
+		return nil, err
+	}
+
+	return &campaign, nil
+}
+
+func ValidateCampaign(campaign *types.Campaign) error {
+	if campaign.Repetitions < 1 {
+		return fmt.Errorf("repetitions must be at least 1, got %d", campaign.Repetitions)
+	}
+
+	experiment := types.DefaultExperiment()
+	for name, values := range campaign.Dimensions {
+		if _, err := experiment.Parameter(name); err != nil {
+			return fmt.Errorf("dimensions: %v", err)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("dimensions: %s has no values", name)
+		}
+	}
+
+	for i, exclude := range campaign.Exclude {
+		for name := range exclude {
+			if _, err := experiment.Parameter(name); err != nil {
+				return fmt.Errorf("exclude[%d]: %v", i, err)
+			}
+		}
+	}
+
+	for i, include := range campaign.Include {
+		for name := range include {
+			if _, err := experiment.Parameter(name); err != nil {
+				return fmt.Errorf("include[%d]: %v", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExpandCampaign returns the experiments declared by the campaign.
+// Parameters which are neither a dimension nor set by an included point keep their default value.
+func ExpandCampaign(campaign *types.Campaign) ([]types.Experiment, error) {
+	if err := ValidateCampaign(campaign); err != nil {
+		return nil, err
+	}
+
+	// base experiment: defaults overridden by the first value of every dimension
+	base := types.DefaultExperiment()
+	for name, values := range campaign.Dimensions {
+		field, _ := base.Parameter(name)
+		*field = values[0]
+	}
+
+	points := []types.Experiment{base}
+	for _, name := range types.ExperimentParameters() {
+		values, ok := campaign.Dimensions[name]
+		if !ok {
+			continue
+		}
+
+		expanded := make([]types.Experiment, 0, len(points)*len(values))
+		for _, point := range points {
+			for _, value := range values {
+				field, _ := point.Parameter(name)
+				*field = value
+				expanded = append(expanded, point)
+			}
+		}
+		points = expanded
+	}
+
+	grid := []types.Experiment{}
+	for _, point := range points {
+		if !isExcluded(point, campaign.Exclude) {
+			grid = append(grid, point)
+		}
+	}
+
+	for _, include := range campaign.Include {
+		point := base
+		for name, value := range include {
+			field, _ := point.Parameter(name)
+			*field = value
+		}
+		if !containsExperiment(grid, point) {
+			grid = append(grid, point)
+		}
+	}
+
+	for _, e := range grid {
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	experiments := []types.Experiment{}
+	for run := 0; run < campaign.Repetitions; run++ {
+		for _, e := range grid {
+			e.RunId = run
+			experiments = append(experiments, e)
+		}
+	}
+
+	return experiments, nil
+}
+
+// isExcluded reports whether the experiment matches all parameters of any of the exclusions.
+func isExcluded(experiment types.Experiment, exclusions []map[string]int) bool {
+	for _, exclude := range exclusions {
+		matches := true
+		for name, value := range exclude {
+			field, _ := experiment.Parameter(name)
+			if *field != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func containsExperiment(experiments []types.Experiment, experiment types.Experiment) bool {
+	for _, e := range experiments {
+		if e == experiment {
+			return true
+		}
+	}
+	return false
+}
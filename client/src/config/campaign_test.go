@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"prinkbenchmarking/src/types"
+)
+
+// shortName names the experiment by the parameters the test campaigns vary.
+func shortName(e types.Experiment) string {
+	return fmt.Sprintf("k%d_l%d_parallelism%d_run%d", e.K, e.L, e.Parallelism, e.RunId)
+}
+
+func TestExpandCampaign(t *testing.T) {
+	tests := []struct {
+		name     string
+		campaign string
+		want     []string
+		wantErr  string
+	}{
+		{
+			name: "grid",
+			campaign: `
+dimensions:
+  k: [5, 10]
+  l: [0, 2]`,
+			want: []string{"k5_l0_parallelism1_run0", "k5_l2_parallelism1_run0", "k10_l0_parallelism1_run0", "k10_l2_parallelism1_run0"},
+		},
+		{
+			name: "repetitions",
+			campaign: `
+repetitions: 2
+dimensions:
+  k: [5, 10]`,
+			want: []string{"k5_l0_parallelism1_run0", "k10_l0_parallelism1_run0", "k5_l0_parallelism1_run1", "k10_l0_parallelism1_run1"},
+		},
+		{
+			name: "exclude",
+			campaign: `
+dimensions:
+  k: [5, 10]
+  l: [0, 2]
+exclude:
+  - {k: 10, l: 2}`,
+			want: []string{"k5_l0_parallelism1_run0", "k5_l2_parallelism1_run0", "k10_l0_parallelism1_run0"},
+		},
+		{
+			name: "exclude by one parameter",
+			campaign: `
+dimensions:
+  k: [5, 10]
+  l: [0, 2]
+exclude:
+  - {l: 2}`,
+			want: []string{"k5_l0_parallelism1_run0", "k10_l0_parallelism1_run0"},
+		},
+		{
+			name: "include starts from the first values",
+			campaign: `
+dimensions:
+  k: [5, 10]
+  l: [0, 2]
+include:
+  - {k: 40}`,
+			want: []string{"k5_l0_parallelism1_run0", "k5_l2_parallelism1_run0", "k10_l0_parallelism1_run0", "k10_l2_parallelism1_run0", "k40_l0_parallelism1_run0"},
+		},
+		{
+			name: "include of a grid point is not duplicated",
+			campaign: `
+dimensions:
+  k: [5, 10]
+include:
+  - {k: 10}`,
+			want: []string{"k5_l0_parallelism1_run0", "k10_l0_parallelism1_run0"},
+		},
+		{
+			name: "include adds an excluded point again",
+			campaign: `
+dimensions:
+  k: [5, 10]
+exclude:
+  - {k: 10}
+include:
+  - {k: 10}`,
+			want: []string{"k5_l0_parallelism1_run0", "k10_l0_parallelism1_run0"},
+		},
+		{
+			name: "include sets a parameter which is no dimension",
+			campaign: `
+dimensions:
+  k: [5]
+include:
+  - {k: 5, taskmanagers: 2, parallelism: 2}`,
+			want: []string{"k5_l0_parallelism1_run0", "k5_l0_parallelism2_run0"},
+		},
+		{
+			name:     "unknown dimension",
+			campaign: `dimensions: {kappa: [5]}`,
+			wantErr:  "dimensions",
+		},
+		{
+			name:     "dimension without values",
+			campaign: `dimensions: {k: []}`,
+			wantErr:  "k has no values",
+		},
+		{
+			name: "unknown parameter in exclude",
+			campaign: `
+dimensions: {k: [5]}
+exclude:
+  - {kappa: 5}`,
+			wantErr: "exclude[0]",
+		},
+		{
+			name: "unknown parameter in include",
+			campaign: `
+dimensions: {k: [5]}
+include:
+  - {kappa: 5}`,
+			wantErr: "include[0]",
+		},
+		{
+			name: "zero repetitions",
+			campaign: `
+repetitions: 0
+dimensions: {k: [5]}`,
+			wantErr: "repetitions",
+		},
+		{
+			name:     "negative repetitions",
+			campaign: `repetitions: -1`,
+			wantErr:  "repetitions",
+		},
+		{
+			name:     "unknown key",
+			campaign: `dimension: {k: [5]}`,
+			wantErr:  "dimension",
+		},
+		{
+			name:     "parallelism exceeding the task slots",
+			campaign: `dimensions: {parallelism: [2]}`,
+			wantErr:  "exceeds",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var experiments []types.Experiment
+			campaign, err := ReadCampaign(strings.NewReader(test.campaign))
+			if err == nil {
+				experiments, err = ExpandCampaign(campaign)
+			}
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			names := []string{}
+			for _, e := range experiments {
+				names = append(names, shortName(e))
+			}
+			if strings.Join(names, " ") != strings.Join(test.want, " ") {
+				t.Errorf("got experiments %v, want %v", names, test.want)
+			}
+		})
+	}
+}
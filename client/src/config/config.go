@@ -1,14 +1,17 @@
 package config
 
 import (
-	"encoding/csv"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"prinkbenchmarking/src/failure"
 	"prinkbenchmarking/src/types"
 
 	"gopkg.in/yaml.v2"
@@ -86,26 +89,136 @@ func ReadConfig(reader io.Reader) (*types.Config, error) {
 		return nil, err
 	}
 
+	if err := ValidateConfig(&config); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
+// ValidateConfig checks the settings which cannot be checked by decoding alone.
+// Settings which are not given are set to their defaults.
+func ValidateConfig(config *types.Config) error {
+	if config.CampaignID == "" {
+		config.CampaignID = "default"
+	}
 
+	switch config.SutMode {
+	case "":
+		config.SutMode = "docker"
+	case "docker":
+	case "fake":
+		// the fake SUT runs in the client's process, so its REST API is only reachable on this machine
+		for _, address := range config.SutAddresses {
+			if ip := net.ParseIP(address); address != "localhost" && (ip == nil || !ip.IsLoopback()) {
+				return fmt.Errorf("sut_mode: fake requires loopback sut_addresses, got %q", address)
+			}
+		}
+		// the fake SUT connects to the client on this machine, the outbound address is not needed
+		if config.LocalAddress == "" {
+			config.LocalAddress = "127.0.0.1"
+		}
+	default:
+		return fmt.Errorf("sut_mode: unknown mode %q", config.SutMode)
+	}
 
-func LoadDataset(path string) [][]string {
-	// load the dataset
-	file, err := os.Open(path)
-	if err != nil {
-		log.Fatalf("Could not open dataset file: %v", err)
+	if config.StartupTimeout == 0 {
+		config.StartupTimeout = 5 * time.Minute
+	}
+	if config.StartupTimeout < 0 {
+		return fmt.Errorf("startup_timeout: must be positive, got %v", config.StartupTimeout)
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	dataset, err := reader.ReadAll()
+	if config.ExperimentTimeout.Base < 0 {
+		return fmt.Errorf("experiment_timeout: base must not be negative, got %v", config.ExperimentTimeout.Base)
+	}
+	for name, factor := range config.ExperimentTimeout.PerParameter {
+		if !slices.Contains(types.ExperimentParameters(), name) {
+			return fmt.Errorf("experiment_timeout: unknown parameter %q", name)
+		}
+		if factor < 0 {
+			return fmt.Errorf("experiment_timeout: duration per %s must not be negative, got %v", name, factor)
+		}
+	}
 
-	if err != nil {
-		log.Fatalf("Could not read dataset file: %v", err)
+	if config.Retry.MaxTries == 0 {
+		config.Retry.MaxTries = 3
+	}
+	if config.Retry.MaxTries < 0 || config.Retry.Backoff < 0 {
+		return fmt.Errorf("retry: max_tries and backoff must not be negative")
+	}
+	for class, policy := range config.Retry.Classes {
+		if !slices.Contains(failure.Classes(), failure.Class(class)) {
+			return fmt.Errorf("retry: unknown failure class %q", class)
+		}
+		if policy.MaxTries < 0 || policy.Backoff < 0 {
+			return fmt.Errorf("retry: max_tries and backoff of %s must not be negative", class)
+		}
 	}
 
-	return dataset
-}
+	if len(config.Schema.Columns) == 0 {
+		config.Schema = DefaultSchema()
+	}
+	if err := validateSchema(config.Schema); err != nil {
+		return err
+	}
+
+	if config.MetricBuffer.Capacity == 0 {
+		config.MetricBuffer.Capacity = 100000
+	}
+	if config.MetricBuffer.Capacity < 0 {
+		return fmt.Errorf("metric_buffer: capacity must be positive, got %d", config.MetricBuffer.Capacity)
+	}
+	switch config.MetricBuffer.Policy {
+	case "":
+		config.MetricBuffer.Policy = "drop_oldest"
+	case "drop_oldest", "drop_newest", "downsample":
+	default:
+		return fmt.Errorf("metric_buffer: unknown policy %q", config.MetricBuffer.Policy)
+	}
+
+	if config.Push.BatchSize == 0 {
+		config.Push.BatchSize = 10000
+	}
+	if config.Push.Interval == 0 {
+		config.Push.Interval = 5 * time.Second
+	}
+	if config.Push.BatchSize < 0 || config.Push.Interval < 0 || config.Push.Retries < 0 {
+		return fmt.Errorf("push: batch_size, interval and retries must not be negative")
+	}
+
+	// a start of 0 is resolved to sut_port_write when the experiments start, after the command line overrides
+	if config.PortRange.End == 0 {
+		config.PortRange.End = 60000
+	}
+	if config.PortRange.Start < 0 || config.PortRange.End > 65535 || config.PortRange.Start > config.PortRange.End {
+		return fmt.Errorf("port_range: invalid range %d-%d", config.PortRange.Start, config.PortRange.End)
+	}
+
+	window := config.Window
+	if window.WarmupRecords < 0 || window.WarmupDuration < 0 || window.CooldownRecords < 0 || window.CooldownDuration < 0 {
+		return fmt.Errorf("window: warm-up and cool-down must not be negative")
+	}
+
+	profile := config.LoadProfile
+	switch profile.Shape {
+	case "", "constant":
+	case "step":
+		if len(profile.Steps) == 0 || profile.StepDuration <= 0 {
+			return fmt.Errorf("load_profile: step shape needs steps and a positive step_duration")
+		}
+		for _, step := range profile.Steps {
+			if step <= 0 {
+				return fmt.Errorf("load_profile: step factors must be positive, got %v", step)
+			}
+		}
+	case "ramp":
+		if profile.RampDuration <= 0 || profile.RampStart < 0 {
+			return fmt.Errorf("load_profile: ramp shape needs a positive ramp_duration and a non-negative ramp_start")
+		}
+	default:
+		return fmt.Errorf("load_profile: unknown shape %q", profile.Shape)
+	}
 
+	return nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+
+	"prinkbenchmarking/src/types"
+)
+
+// DefaultSchema returns the schema of the ASHRAE building energy dataset,
+// used if the config does not declare one.
+func DefaultSchema() types.Schema {
+	return types.Schema{
+		Columns: []types.Column{
+			{Name: "building_id", Type: "int", Role: types.RoleID, Label: true},
+			{Name: "timestamp", Type: "time", Role: types.RoleTimestamp},
+			{Name: "meter_reading", Type: "float", Role: types.RoleSensitive, Export: true},
+			{Name: "primary_use", Type: "string", Role: types.RoleQuasiIdentifier, Label: true},
+			{Name: "square_feet", Type: "float", Role: types.RoleQuasiIdentifier, Export: true},
+			{Name: "year_built", Type: "float", Role: types.RoleQuasiIdentifier},
+			{Name: "floor_count", Type: "float", Role: types.RoleQuasiIdentifier},
+			{Name: "air_temperature", Type: "float"},
+			{Name: "cloud_coverage", Type: "float"},
+			{Name: "dew_temperature", Type: "float"},
+			{Name: "precip_depth_1_hr", Type: "float"},
+			{Name: "sea_level_pressure", Type: "float"},
+			{Name: "wind_direction", Type: "float"},
+			{Name: "wind_speed", Type: "float"},
+			{Name: "building_id2", Type: "int"},
+			{Name: "unixTimestamp", Type: "int"},
+		},
+	}
+}
+
+func validateSchema(schema types.Schema) error {
+	if len(schema.Columns) == 0 {
+		return fmt.Errorf("schema: no columns")
+	}
+
+	names := map[string]bool{}
+	roles := map[string]int{}
+	for _, column := range schema.Columns {
+		if column.Name == "" {
+			return fmt.Errorf("schema: column without name")
+		}
+		if names[column.Name] {
+			return fmt.Errorf("schema: duplicate column %s", column.Name)
+		}
+		names[column.Name] = true
+
+		switch column.Type {
+		case "string", "int", "float", "time":
+		default:
+			return fmt.Errorf("schema: column %s has unknown type %q", column.Name, column.Type)
+		}
+
+		switch column.Role {
+		case "", types.RoleID, types.RoleTimestamp, types.RoleQuasiIdentifier, types.RoleSensitive:
+		default:
+			return fmt.Errorf("schema: column %s has unknown role %q", column.Name, column.Role)
+		}
+		roles[column.Role]++
+
+		if column.Export && column.Type != "int" && column.Type != "float" {
+			return fmt.Errorf("schema: column %s is exported but not numeric", column.Name)
+		}
+	}
+
+	for _, role := range []string{types.RoleID, types.RoleTimestamp, types.RoleSensitive} {
+		if roles[role] > 1 {
+			return fmt.Errorf("schema: more than one column with role %s", role)
+		}
+	}
+	if roles[types.RoleTimestamp] == 0 {
+		return fmt.Errorf("schema: no column with role %s", types.RoleTimestamp)
+	}
+
+	return nil
+}
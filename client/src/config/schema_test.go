@@ -0,0 +1,52 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"prinkbenchmarking/src/types"
+)
+
+func TestValidateSchema(t *testing.T) {
+	timestamp := types.Column{Name: "timestamp", Type: "time", Role: types.RoleTimestamp}
+
+	tests := []struct {
+		name    string
+		columns []types.Column
+		wantErr string
+	}{
+		{name: "default schema", columns: DefaultSchema().Columns},
+		{name: "only a timestamp", columns: []types.Column{timestamp}},
+		{name: "no columns", wantErr: "no columns"},
+		{name: "column without name", columns: []types.Column{timestamp, {Type: "int"}}, wantErr: "without name"},
+		{name: "duplicate column", columns: []types.Column{timestamp, timestamp}, wantErr: "duplicate column timestamp"},
+		{name: "unknown type", columns: []types.Column{timestamp, {Name: "zip", Type: "zip"}}, wantErr: "unknown type"},
+		{name: "unknown role", columns: []types.Column{timestamp, {Name: "zip", Type: "int", Role: "qi"}}, wantErr: "unknown role"},
+		{name: "exported string", columns: []types.Column{timestamp, {Name: "use", Type: "string", Export: true}}, wantErr: "not numeric"},
+		{
+			name:    "two sensitive columns",
+			columns: []types.Column{timestamp, {Name: "a", Type: "int", Role: types.RoleSensitive}, {Name: "b", Type: "int", Role: types.RoleSensitive}},
+			wantErr: "more than one column with role sensitive",
+		},
+		{
+			name:    "several quasi-identifiers",
+			columns: []types.Column{timestamp, {Name: "a", Type: "int", Role: types.RoleQuasiIdentifier}, {Name: "b", Type: "int", Role: types.RoleQuasiIdentifier}},
+		},
+		{name: "no timestamp", columns: []types.Column{{Name: "zip", Type: "int"}}, wantErr: "no column with role timestamp"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateSchema(types.Schema{Columns: test.columns})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package dataset
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Reader reads the records of a dataset one after another.
+type Reader interface {
+	// Header returns the column names of the dataset.
+	Header() []string
+	// Next returns the next record, or io.EOF after the last one.
+	Next() ([]string, error)
+	Close() error
+}
+
+// Dataset is a CSV file with a header line whose records are sent to the SUT.
+// Datasets whose parsed records fit into the cache limit are kept in memory after they were read once,
+// larger ones are streamed from disk by every reader.
+type Dataset struct {
+	path       string
+	cacheLimit int64
+
+	// guards the fields below, which are only set once they were read successfully,
+	// so a failed read is tried again by the next experiment
+	mtx      sync.Mutex
+	header   []string
+	records  [][]string
+	cached   bool
+	streamed bool
+	count    int
+	counted  bool
+	checksum string
+}
+
+var (
+	datasets    = map[string]*Dataset{}
+	datasetsMtx sync.Mutex
+)
+
+// Shared returns the dataset at path, shared by all experiments using the same file.
+// cacheLimitMB is the memory up to which the parsed dataset is cached, 0 disables caching.
+func Shared(path string, cacheLimitMB int) *Dataset {
+	datasetsMtx.Lock()
+	defer datasetsMtx.Unlock()
+
+	dataset, ok := datasets[path]
+	if !ok {
+		dataset = &Dataset{path: path, cacheLimit: int64(cacheLimitMB) * 1024 * 1024}
+		datasets[path] = dataset
+	}
+	return dataset
+}
+
+// Reader returns a new reader positioned at the first record of the dataset.
+func (d *Dataset) Reader() (Reader, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if !d.cached && !d.streamed {
+		size, err := memorySize(d.path)
+		if err != nil {
+			return nil, err
+		}
+		if size > d.cacheLimit {
+			d.streamed = true
+		} else if err := d.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.streamed {
+		return newFileReader(d.path)
+	}
+	return &cachedReader{header: d.header, records: d.records}, nil
+}
+
+// Count returns the number of records of the dataset.
+// Datasets which are not cached are read once to count their records.
+func (d *Dataset) Count() (int, error) {
+	reader, err := d.Reader()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	if cached, ok := reader.(*cachedReader); ok {
+		return len(cached.records), nil
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.counted {
+		return d.count, nil
+	}
+
+	count := 0
+	for {
+		_, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+	d.count, d.counted = count, true
+	return d.count, nil
+}
+
+// Checksum returns the hex encoded SHA-256 of the dataset file, computed once.
+func (d *Dataset) Checksum() (string, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.checksum != "" {
+		return d.checksum, nil
+	}
+
+	file, err := os.Open(d.path)
+	if err != nil {
+		return "", fmt.Errorf("could not open dataset file: %v", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("could not read dataset file: %v", err)
+	}
+	d.checksum = hex.EncodeToString(hash.Sum(nil))
+	return d.checksum, nil
+}
+
+func (d *Dataset) load() error {
+	reader, err := newFileReader(d.path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	records := [][]string{}
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+
+	d.header, d.records, d.cached = reader.Header(), records, true
+	return nil
+}
+
+// sampleRecords is the number of records memorySize extrapolates the size of the parsed dataset from.
+const sampleRecords = 1000
+
+// memorySize estimates the memory the parsed records of the dataset take. Besides the field data,
+// every field is a string header of 16 bytes and every record a slice header of 24 bytes,
+// so a dataset of short fields takes several times its file size.
+func memorySize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not open dataset file: %v", err)
+	}
+	reader, err := newFileReader(path)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	start := reader.csv.InputOffset()
+	records, fields := int64(0), int64(0)
+	for records < sampleRecords {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		records++
+		fields += int64(len(record))
+	}
+	sampled := reader.csv.InputOffset() - start
+	if records == 0 || sampled == 0 {
+		return info.Size(), nil
+	}
+
+	total := (info.Size() - start) * records / sampled
+	return info.Size() + total*(24+16*fields/records), nil
+}
+
+type fileReader struct {
+	file   *os.File
+	csv    *csv.Reader
+	header []string
+}
+
+func newFileReader(path string) (*fileReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open dataset file: %v", err)
+	}
+
+	reader := csv.NewReader(bufio.NewReaderSize(file, 1024*1024))
+	header, err := reader.Read()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not read dataset header: %v", err)
+	}
+
+	return &fileReader{file: file, csv: reader, header: header}, nil
+}
+
+func (r *fileReader) Header() []string {
+	return r.header
+}
+
+func (r *fileReader) Next() ([]string, error) {
+	record, err := r.csv.Read()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("could not read dataset file: %v", err)
+	}
+	return record, err
+}
+
+func (r *fileReader) Close() error {
+	return r.file.Close()
+}
+
+type cachedReader struct {
+	header  []string
+	records [][]string
+	next    int
+}
+
+func (r *cachedReader) Header() []string {
+	return r.header
+}
+
+func (r *cachedReader) Next() ([]string, error) {
+	if r.next >= len(r.records) {
+		return nil, io.EOF
+	}
+	record := r.records[r.next]
+	r.next++
+	return record, nil
+}
+
+func (r *cachedReader) Close() error {
+	return nil
+}
@@ -2,42 +2,94 @@ package evaluation
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"prinkbenchmarking/src/dataset"
 	"prinkbenchmarking/src/exporter"
+	"prinkbenchmarking/src/failure"
 	"prinkbenchmarking/src/types"
 	"strings"
 	"time"
 )
 
-func benchmark(dataset [][]string, conn net.Conn, experiment *types.Experiment) error {
+func benchmark(records dataset.Reader, conn net.Conn, experiment *types.Experiment, phases *phases, config types.Config) error {
 	// benchmark the SUT
-	// Iterate over the records for duration seconds and write them to console (for now)
+	// Iterate over the records and write them to the SUT, paced to the experiment's rate if it has one
 	count := 0
-	start := time.Now()
-	for i, record := range dataset {
-		if i == 0 {
-			continue
+	pacer := newPacer(experiment.Rate, config.LoadProfile)
+	stats := &pacingStats{}
+	defer func() {
+		if err := stats.save(config.OutputFolder, experiment); err != nil {
+			log.Printf("Could not save pacing statistics: %v", err)
 		}
+	}()
 
-		// Export record as prometheus Gauge
-		exporter.ExportRecordAsPrometheusGaugeRaw(record, experiment)
+	if columns := len(records.Header()); columns != len(config.Schema.Columns) {
+		return fmt.Errorf("dataset has %d columns but the schema %d", columns, len(config.Schema.Columns))
+	}
+
+	transfer, err := exporter.NewTransfer(experiment)
+	if err != nil {
+		return err
+	}
+
+	timestamp := -1
+	for i, column := range config.Schema.Columns {
+		if column.Role == types.RoleTimestamp {
+			timestamp = i
+		}
+	}
+	layout := config.Schema.Layout()
+
+	start := time.Now()
+	for {
+		record, err := records.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
 
 		// Data fields:
-		// building_id, timestamp, meter_reading, primary_use, square_feet, year_built, floor_count, air_temperature, cloud_coverage, dew_temperature, precip_depth_1_hr, sea_level_pressure, wind_direction, wind_speed, building_id2, unix_timestamp,
+		// the columns of config.Schema
 		//
 		// Benchmark fields (append to the end):
 		// m_id, ts
 
+		// without a rate the intended send time is the actual one
+		intended := time.Since(start)
+		targetRate := 0.0
+		if experiment.Rate > 0 {
+			intended, targetRate = pacer.next()
+			if wait := time.Until(start.Add(intended)); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
 		ts := time.Now()
 
+		// the phase is fixed before the record is written, so the reader always finds it
+		if phases.begin(count, ts.Sub(start)) == types.PhaseMeasurement {
+			// Export record as prometheus Gauge
+			exporter.ExportRecordAsPrometheusGaugeRaw(record, experiment)
+		}
+
 		message := strings.Join(record, ";") + fmt.Sprintf(";%d;%v\n", count, ts)
 
 		// Write the message to Flink socket
-		_, err := conn.Write([]byte(message))
+		_, err = conn.Write([]byte(message))
 		if err != nil {
-			return fmt.Errorf("could not write to Flink: %v", err)
+			return failure.Errorf(failure.SocketWrite, "could not write to Flink: %v", err)
+		}
+		transfer.RecordSent(len(message))
+		if timestamp >= 0 {
+			if eventTime, err := time.Parse(layout, record[timestamp]); err == nil {
+				phases.observeEventTime(eventTime)
+			}
 		}
+		stats.add(intended, ts.Sub(start), targetRate)
 
 		count++
 	}
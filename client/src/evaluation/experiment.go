@@ -1,22 +1,39 @@
 package evaluation
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	cfg "prinkbenchmarking/src/config"
+	"prinkbenchmarking/src/dataset"
+	"prinkbenchmarking/src/exporter"
+	"prinkbenchmarking/src/fakesut"
+	"prinkbenchmarking/src/failure"
 	"prinkbenchmarking/src/prink"
 	"prinkbenchmarking/src/types"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
-func RunSockets(experiment* types.Experiment, config types.Config) bool {
-	dataset := cfg.LoadDataset(config.InputData)
+func RunSockets(ctx context.Context, experiment* types.Experiment, config types.Config) error {
+	phases, err := newExperimentPhases(experiment, config)
+	if err != nil {
+		return fmt.Errorf("could not load dataset: %v", err)
+	}
+	return runSockets(ctx, experiment, phases, config)
+}
+
+// runSockets sends the dataset to Prink and reads the results until Prink closes the connections.
+// Cancelling the context closes the sockets and fails the experiment with the cause of the cancellation.
+func runSockets(ctx context.Context, experiment *types.Experiment, phases *phases, config types.Config) error {
+	records, err := dataset.Shared(config.InputData, config.DatasetCacheMB).Reader()
+	if err != nil {
+		return fmt.Errorf("could not load dataset: %v", err)
+	}
+	defer records.Close()
 
-	success := atomic.Int32{}
+	var writeErr, readErr error
 	var wg sync.WaitGroup
 
 	// Increment the WaitGroup counter
@@ -25,88 +42,210 @@ func RunSockets(experiment* types.Experiment, config types.Config) bool {
 	// write socket connection
 	go func() {
 		defer wg.Done() // Decrement the counter when the goroutine completes
-		if err := socketConnection(experiment, dataset); err != nil {
-			log.Println("Error in socket connection: ", err)
-			success.Add(1)
+		if writeErr = socketConnection(ctx, experiment, records, phases, config); writeErr != nil {
+			log.Println("Error in socket connection: ", writeErr)
 		}
 	}()
 
 	// read socket connection
 	go func() {
 		defer wg.Done() // Decrement the counter when the goroutine completes
-		if err := readSocketConnection(experiment, config); err != nil {
-			log.Println("Error in socket connection: ", err)
-			success.Add(1)
+		if readErr = readSocketConnection(ctx, experiment, phases, config); readErr != nil {
+			log.Println("Error in socket connection: ", readErr)
 		}
 	}()
 
 	// Wait for all goroutines to finish
 	wg.Wait()
-	return success.Load() == 0
+	if ctx.Err() != nil {
+		log.Printf("Sockets of %v closed: %v", experiment.ToFileName(), context.Cause(ctx))
+		return context.Cause(ctx)
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	return readErr
 }
 
-func RunExperiment(experiment types.Experiment, config types.Config) bool {
-	
-	success := atomic.Bool{}
+// RunExperiment runs Prink and the sockets of the experiment until Prink is done, and saves its results.
+// If the context is cancelled or the experiment times out, sending stops and the results read so far,
+// the flamegraph and the logs are saved before Prink is removed.
+// It returns nil if the experiment succeeded, otherwise the error is classified by package failure where possible.
+func RunExperiment(parent context.Context, experiment types.Experiment, config types.Config) error {
+	manifest := newManifest(&experiment, config)
+
+	phases, err := newExperimentPhases(&experiment, config)
+	if err != nil {
+		log.Println("Error in loading dataset: ", err)
+		manifest.finish(&experiment, config, OutcomeFailed)
+		return fmt.Errorf("could not load dataset: %v", err)
+	}
+
+	// cancelled if the job does not start, the experiment times out or the client shuts down,
+	// which stops the SUT and closes the sockets
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	if timeout := config.ExperimentTimeout.Of(experiment); timeout > 0 {
+		watchdog := time.AfterFunc(timeout, func() {
+			log.Printf("Experiment %v timed out after %v", experiment.ToFileName(), timeout)
+			cancel(failure.Errorf(failure.Timeout, "experiment timed out after %v", timeout))
+		})
+		defer watchdog.Stop()
+	}
+
+	var socketErr error
 	var wg sync.WaitGroup
 	// Increment the WaitGroup counter
 	wg.Add(2)
 
+	// closed once the containers are started, the image pull does not count towards the startup timeout
+	started := make(chan bool)
+
 	// start prink
 	go func() {
 		defer wg.Done() // Decrement the counter when the goroutine completes
-		if err := prink.StartPrink(&experiment, config); err != nil {
+		if err := startSut(ctx, &experiment, config, started); err != nil {
 			log.Println("Error in prink: ", err)
+			// the sockets would wait for a job which is gone
+			cancel(fmt.Errorf("SUT stopped: %w", err))
 		}
 	}()
 	
 
 	go func() {
 		defer wg.Done() // Decrement the counter when the goroutine completes
-		success.Store(RunSockets(&experiment, config))
+		socketErr = runSockets(ctx, &experiment, phases, config)
+	}()
+
+	// closed once the job is running or did not start
+	ready := make(chan bool)
+	go func() {
+		defer close(ready)
+		select {
+		case <-started:
+		case <-ctx.Done():
+			return
+		}
+		startup, err := prink.WaitForJob(ctx, &experiment, config.StartupTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Prink job of %v did not start: %v", experiment.ToFileName(), err)
+				manifest.Errors = append(manifest.Errors, fmt.Sprintf("job did not start: %v", err))
+				cancel(err)
+			}
+			return
+		}
+		log.Printf("Prink job of %v running after %v", experiment.ToFileName(), startup.Round(time.Millisecond))
+		manifest.StartupSeconds = startup.Seconds()
+		exporter.ObserveStartup(&experiment, startup)
 	}()
 
 	ticker := time.NewTicker(time.Second)
 	done := make(chan bool)
+	// closed once the flamegraph is saved
+	saved := make(chan bool)
 
 	go func() {
-		var fg *prink.Flamegraph
+		// merge the samples taken during the measurement, Flink returns the same sample until it takes a new one
+		fg := &prink.Flamegraph{Name: "root"}
+		samples := 0
+		lastSample := 0
 		var prevError string
 		for {
 			select {
 			case <-done:
-			  if err := SaveFlamegraph(fg, &experiment, config); err != nil {
+				log.Printf("Merged %d flamegraph samples of %v", samples, experiment.ToFileName())
+				if err := SaveFlamegraph(fg, &experiment, config); err != nil {
 					log.Println("Error in saving flamegraph: ", err)
 				}
+				close(saved)
 				return
 			case <-ticker.C:
 				flamegraph, err := prink.GetProfilingData(&experiment, config);
-				if err != nil && err.Error() != prevError {
-					log.Println("Error in prink profiling: ", err)
-					prevError = err.Error()
+				if err != nil {
+					if err.Error() != prevError {
+						log.Println("Error in prink profiling: ", err)
+						prevError = err.Error()
+					}
+					continue
+				}
+				if !phases.measuring() || flamegraph.EndTimestamp == lastSample {
 					continue
 				}
-				fg = flamegraph
+				fg.Merge(flamegraph.Data)
+				lastSample = flamegraph.EndTimestamp
+				samples++
 			}
 		}
 	}()
 
+	stopMetrics := make(chan bool)
+	// closed once the vertex metrics are written
+	collected := make(chan bool)
+	go func() {
+		if err := collectVertexMetrics(&experiment, phases, config, stopMetrics); err != nil {
+			log.Println("Error in saving vertex metrics: ", err)
+		}
+		close(collected)
+	}()
+
 	// Wait for all goroutines to finish
 	wg.Wait()
+	cancel(nil)
+	<-ready
 	ticker.Stop()
 	done <- true
-	return success.Load()
+	close(stopMetrics)
+	<-saved
+	<-collected
+	// ship the samples of the experiment before the next one starts
+	if err := exporter.Flush(); err != nil {
+		log.Println("Error in pushing metrics: ", err)
+	}
+	switch {
+	case parent.Err() != nil:
+		manifest.finish(&experiment, config, OutcomeInterrupted)
+		return context.Cause(parent)
+	case socketErr == nil:
+		manifest.finish(&experiment, config, OutcomeSucceeded)
+		return nil
+	case failure.ClassOf(socketErr) == failure.Timeout:
+		manifest.finish(&experiment, config, OutcomeTimedOut)
+		return socketErr
+	}
+	manifest.finish(&experiment, config, OutcomeFailed)
+	return socketErr
 }
 
+// startSut runs the system under test selected by the config until the experiment is done or cancelled.
+// started is closed once the SUT is started.
+func startSut(ctx context.Context, experiment *types.Experiment, config types.Config, started chan<- bool) error {
+	if config.SutMode == "fake" {
+		return fakesut.Run(ctx, experiment, config, started)
+	}
+	return prink.StartPrink(ctx, experiment, config, started)
+}
 
+// SaveFlamegraph writes the flamegraph as JSON and in the folded stacks format into the output folder.
 func SaveFlamegraph(fg *prink.Flamegraph, experiment *types.Experiment, config types.Config) error {
 	// save flamegraph
-	filename := fmt.Sprintf("%s/flamegraph-%s.%s.json", config.OutputFolder, time.Now().Format("2006-01-02.15:04:05"), experiment.ToFileName())
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	filename := fmt.Sprintf("%s/flamegraph-%s.%s", config.OutputFolder, time.Now().Format("2006-01-02.15:04:05"), experiment.ToFileName())
+	file, err := os.OpenFile(filename+".json", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		log.Printf("Could not open flamegraph file: %v", err)
 	}
 	defer file.Close()
 
-	return json.NewEncoder(file).Encode(fg)
-}
\ No newline at end of file
+	if err := json.NewEncoder(file).Encode(fg); err != nil {
+		return err
+	}
+
+	folded, err := os.Create(filename + ".folded")
+	if err != nil {
+		return err
+	}
+	defer folded.Close()
+
+	return fg.WriteFolded(folded)
+}
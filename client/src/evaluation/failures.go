@@ -0,0 +1,93 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"prinkbenchmarking/src/failure"
+	"prinkbenchmarking/src/types"
+	"sync"
+	"time"
+)
+
+// Attempt is a failed try of an experiment.
+type Attempt struct {
+	Experiment string        `json:"experiment"`
+	Try        int           `json:"try"`
+	SutHost    string        `json:"sut_host"`
+	Start      time.Time     `json:"start"`
+	End        time.Time     `json:"end"`
+	Class      failure.Class `json:"class"`
+	Error      string        `json:"error"`
+	// Retried is set if the experiment is tried again after Backoff
+	Retried bool   `json:"retried"`
+	Backoff string `json:"backoff,omitempty"`
+}
+
+// FailureLog persists every failed try of the experiments of a campaign in failures.json in the output folder.
+// Like the journal it is kept across restarts of the client.
+type FailureLog struct {
+	path     string
+	mtx      sync.Mutex
+	attempts []Attempt
+}
+
+// OpenFailureLog loads the failure log from the output folder, or starts an empty one if there is none yet.
+func OpenFailureLog(folder string) (*FailureLog, error) {
+	failures := &FailureLog{path: folder + "/failures.json", attempts: []Attempt{}}
+
+	data, err := os.ReadFile(failures.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return failures, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &failures.attempts); err != nil {
+		return nil, fmt.Errorf("could not decode failure log %s: %v", failures.path, err)
+	}
+	return failures, nil
+}
+
+// Add records the failed try of the experiment and the backoff until it is retried, if it is.
+func (l *FailureLog) Add(experiment types.Experiment, start time.Time, err error, retried bool, backoff time.Duration) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	attempt := Attempt{
+		Experiment: experiment.ToFileName(),
+		Try:        experiment.Try,
+		SutHost:    experiment.SutHost,
+		Start:      start,
+		End:        time.Now(),
+		Class:      failure.ClassOf(err),
+		Error:      err.Error(),
+	}
+	if retried {
+		attempt.Retried = true
+		attempt.Backoff = backoff.String()
+	}
+	l.attempts = append(l.attempts, attempt)
+
+	return l.save()
+}
+
+// save atomically replaces the failure log file like the journal.
+func (l *FailureLog) save() error {
+	data, err := json.MarshalIndent(l.attempts, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
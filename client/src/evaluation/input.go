@@ -1,29 +1,61 @@
 package evaluation
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"prinkbenchmarking/src/dataset"
+	"prinkbenchmarking/src/failure"
 	"prinkbenchmarking/src/types"
+	"syscall"
 	"time"
 )
 
 
-func socketConnection(e *types.Experiment, dataset [][]string) error {
+func socketConnection(ctx context.Context, e *types.Experiment, records dataset.Reader, phases *phases, config types.Config) error {
 	// Open socket connection
 	ln, err := net.Listen("tcp", fmt.Sprintf("%s:%d", "0.0.0.0", e.SutPortWrite))
-	ln.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Minute))
 	if err != nil {
-		return fmt.Errorf("could not open socket connection: %v", err)
+		return listenError("could not open socket connection", err)
 	}
+	ln.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Minute))
 	defer ln.Close()
+	defer closeOnDone(ctx, ln)()
 
 	// Accept connection
 	conn, err := ln.Accept()
 	if err != nil {
-		return fmt.Errorf("could not accept connection: %v", err)
+		return acceptError("could not accept connection", err)
 	}
 	defer conn.Close()
+	defer closeOnDone(ctx, conn)()
 
 	// Handle connection
-	return benchmark(dataset, conn, e)
-}
\ No newline at end of file
+	return benchmark(records, conn, e, phases, config)
+}
+
+// acceptError classifies the error of an accept, which times out if Prink does not connect.
+func acceptError(message string, err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return failure.Errorf(failure.ListenerTimeout, "%s: %v", message, err)
+	}
+	return fmt.Errorf("%s: %v", message, err)
+}
+
+// listenError classifies the error of a listen, which fails if another program bound the port since it was allocated.
+func listenError(message string, err error) error {
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return failure.Errorf(failure.PortConflict, "%s: %v", message, err)
+	}
+	return fmt.Errorf("%s: %v", message, err)
+}
+
+// closeOnDone closes the listener or connection once the experiment is cancelled, which aborts blocking accepts, reads and writes.
+// The returned function stops watching the context.
+func closeOnDone(ctx context.Context, c io.Closer) func() {
+	stop := context.AfterFunc(ctx, func() { c.Close() })
+	return func() { stop() }
+}
@@ -0,0 +1,56 @@
+package evaluation
+
+import (
+	"errors"
+	"net"
+	"os"
+	"prinkbenchmarking/src/failure"
+	"syscall"
+	"testing"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestSocketErrors(t *testing.T) {
+	inUse := &net.OpError{Op: "listen", Net: "tcp", Err: os.NewSyscallError("bind", syscall.EADDRINUSE)}
+
+	tests := []struct {
+		name     string
+		classify func(message string, err error) error
+		err      error
+		want     failure.Class
+	}{
+		{name: "port in use", classify: listenError, err: inUse, want: failure.PortConflict},
+		{name: "listen failed", classify: listenError, err: errors.New("permission denied"), want: failure.Unknown},
+		{name: "accept timed out", classify: acceptError, err: &net.OpError{Op: "accept", Net: "tcp", Err: timeoutError{}}, want: failure.ListenerTimeout},
+		{name: "accept failed", classify: acceptError, err: net.ErrClosed, want: failure.Unknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := failure.ClassOf(test.classify("could not open socket", test.err)); got != test.want {
+				t.Errorf("classified %v as %s, want %s", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestListenOnBoundPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "0.0.0.0:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	_, err = net.Listen("tcp", ln.Addr().String())
+	if err == nil {
+		t.Skip("port can be bound twice")
+	}
+	if got := failure.ClassOf(listenError("could not open socket", err)); got != failure.PortConflict {
+		t.Errorf("classified %v as %s, want %s", err, got, failure.PortConflict)
+	}
+}
@@ -0,0 +1,196 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"prinkbenchmarking/src/failure"
+	"prinkbenchmarking/src/types"
+	"sync"
+	"time"
+)
+
+type ExperimentState string
+
+const (
+	StatePending   ExperimentState = "pending"
+	StateRunning   ExperimentState = "running"
+	StateSucceeded ExperimentState = "succeeded"
+	StateFailed    ExperimentState = "failed"
+	// StateInterrupted experiments were stopped by a shutdown of the client and are run again on resume
+	StateInterrupted ExperimentState = "interrupted"
+	// StateTimedOut experiments were cancelled after their experiment_timeout, which counts as a failed try
+	StateTimedOut ExperimentState = "timed_out"
+)
+
+// JournalEntry is the state of one experiment of a campaign.
+type JournalEntry struct {
+	Experiment string          `json:"experiment"`
+	State      ExperimentState `json:"state"`
+	Tries      int             `json:"tries"`
+	// Failure is the class of the failure of the last try
+	Failure failure.Class `json:"failure,omitempty"`
+	Updated time.Time     `json:"updated"`
+}
+
+// Remaining reports whether the experiment still has to be run under the retry policy of its last failure.
+func (e JournalEntry) Remaining(retry types.Retry) bool {
+	return e.State != StateSucceeded && e.Tries < retry.Policy(string(e.Failure)).MaxTries
+}
+
+// Journal persists the state of the experiments of a campaign in the output folder,
+// so a restarted client can skip the experiments which are already done.
+type Journal struct {
+	path    string
+	mtx     sync.Mutex
+	entries []*JournalEntry
+	index   map[string]*JournalEntry
+}
+
+// OpenJournal loads the journal from the output folder, or starts an empty one if there is none yet.
+func OpenJournal(folder string) (*Journal, error) {
+	journal := &Journal{
+		path:  folder + "/journal.json",
+		index: map[string]*JournalEntry{},
+	}
+
+	data, err := os.ReadFile(journal.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return journal, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &journal.entries); err != nil {
+		return nil, fmt.Errorf("could not decode journal %s: %v", journal.path, err)
+	}
+	for _, entry := range journal.entries {
+		journal.index[entry.Experiment] = entry
+	}
+
+	return journal, nil
+}
+
+// Resume registers the experiments and returns those which still have to be run.
+// Experiments which succeeded or failed as often as the retry policy allows are skipped,
+// the others continue with the number of tries recorded in the journal.
+func (j *Journal) Resume(experiments []types.Experiment, retry types.Retry) ([]types.Experiment, error) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	remaining := []types.Experiment{}
+	for _, experiment := range experiments {
+		entry := j.entry(experiment)
+		if !entry.Remaining(retry) {
+			continue
+		}
+
+		// a running experiment was interrupted by a crash, it is run again
+		experiment.Try = entry.Tries
+		remaining = append(remaining, experiment)
+	}
+
+	return remaining, j.save()
+}
+
+// Entry returns the state of the experiment without registering it.
+func (j *Journal) Entry(experiment types.Experiment) JournalEntry {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if entry, ok := j.index[experiment.ToFileName()]; ok {
+		return *entry
+	}
+	return JournalEntry{Experiment: experiment.ToFileName(), State: StatePending}
+}
+
+// Entries returns the states of all experiments in the journal.
+func (j *Journal) Entries() []JournalEntry {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	entries := make([]JournalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+// Start marks the experiment as running and counts the try.
+func (j *Journal) Start(experiment types.Experiment) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	entry := j.entry(experiment)
+	entry.State = StateRunning
+	entry.Tries++
+	entry.Updated = time.Now()
+
+	return j.save()
+}
+
+// Finish records the outcome of the experiment's current try, which failed with err unless it is nil.
+func (j *Journal) Finish(experiment types.Experiment, err error) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	entry := j.entry(experiment)
+	entry.State = StateSucceeded
+	entry.Failure = ""
+	if err != nil {
+		entry.State = StateFailed
+		entry.Failure = failure.ClassOf(err)
+		if entry.Failure == failure.Timeout {
+			entry.State = StateTimedOut
+		}
+	}
+	entry.Updated = time.Now()
+
+	return j.save()
+}
+
+// Interrupt records that the experiment's current try was stopped by a shutdown.
+// The try is not counted, so a restarted client runs the experiment as often as if it had never started.
+func (j *Journal) Interrupt(experiment types.Experiment) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	entry := j.entry(experiment)
+	entry.State = StateInterrupted
+	entry.Tries = max(entry.Tries-1, 0)
+	entry.Updated = time.Now()
+
+	return j.save()
+}
+
+// entry returns the entry of the experiment, adding a pending one if it is unknown.
+func (j *Journal) entry(experiment types.Experiment) *JournalEntry {
+	name := experiment.ToFileName()
+	entry, ok := j.index[name]
+	if !ok {
+		entry = &JournalEntry{Experiment: name, State: StatePending, Updated: time.Now()}
+		j.entries = append(j.entries, entry)
+		j.index[name] = entry
+	}
+	return entry
+}
+
+// save atomically replaces the journal file, so a crash never leaves a truncated journal behind.
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
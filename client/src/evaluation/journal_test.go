@@ -0,0 +1,105 @@
+package evaluation
+
+import (
+	"errors"
+	"prinkbenchmarking/src/failure"
+	"prinkbenchmarking/src/types"
+	"testing"
+)
+
+func TestJournalResume(t *testing.T) {
+	retry := types.Retry{
+		RetryPolicy: types.RetryPolicy{MaxTries: 3},
+		Classes: map[string]types.RetryPolicy{
+			string(failure.JobFailed): {MaxTries: 5},
+			string(failure.Timeout):   {MaxTries: 1},
+		},
+	}
+
+	// tries of the experiment before the client is restarted
+	start := func(j *Journal, e types.Experiment) error { return j.Start(e) }
+	succeed := func(j *Journal, e types.Experiment) error { return j.Finish(e, nil) }
+	fail := func(class failure.Class) func(j *Journal, e types.Experiment) error {
+		return func(j *Journal, e types.Experiment) error {
+			return j.Finish(e, failure.New(class, errors.New("failed")))
+		}
+	}
+	interrupt := func(j *Journal, e types.Experiment) error { return j.Interrupt(e) }
+
+	tests := []struct {
+		name  string
+		tries []func(j *Journal, e types.Experiment) error
+		// whether the experiment is resumed and with how many tries
+		resumed bool
+		try     int
+		state   ExperimentState
+	}{
+		{name: "not started", resumed: true, state: StatePending},
+		{name: "succeeded", tries: []func(*Journal, types.Experiment) error{start, succeed}, try: 1, state: StateSucceeded},
+		{name: "failed once", tries: []func(*Journal, types.Experiment) error{start, fail(failure.Unknown)}, resumed: true, try: 1, state: StateFailed},
+		{name: "failed as often as allowed", tries: []func(*Journal, types.Experiment) error{
+			start, fail(failure.Unknown), start, fail(failure.Unknown), start, fail(failure.Unknown),
+		}, try: 3, state: StateFailed},
+		{name: "class allows more tries", tries: []func(*Journal, types.Experiment) error{
+			start, fail(failure.JobFailed), start, fail(failure.JobFailed), start, fail(failure.JobFailed),
+		}, resumed: true, try: 3, state: StateFailed},
+		{name: "class allows a single try", tries: []func(*Journal, types.Experiment) error{start, fail(failure.Timeout)}, try: 1, state: StateTimedOut},
+		{name: "succeeded after a failure", tries: []func(*Journal, types.Experiment) error{
+			start, fail(failure.Unknown), start, succeed,
+		}, try: 2, state: StateSucceeded},
+		{name: "crashed while running", tries: []func(*Journal, types.Experiment) error{start}, resumed: true, try: 1, state: StateRunning},
+		{name: "interrupted", tries: []func(*Journal, types.Experiment) error{start, interrupt}, resumed: true, state: StateInterrupted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			folder := t.TempDir()
+			experiment := types.DefaultExperiment()
+			other := experiment
+			other.K = 10
+
+			journal, err := OpenJournal(folder)
+			if err != nil {
+				t.Fatalf("could not open journal: %v", err)
+			}
+			for _, try := range test.tries {
+				if err := try(journal, experiment); err != nil {
+					t.Fatalf("could not update journal: %v", err)
+				}
+			}
+
+			// a restarted client reads the journal from the output folder
+			journal, err = OpenJournal(folder)
+			if err != nil {
+				t.Fatalf("could not reopen journal: %v", err)
+			}
+			remaining, err := journal.Resume([]types.Experiment{experiment, other}, retry)
+			if err != nil {
+				t.Fatalf("could not resume: %v", err)
+			}
+
+			want := []types.Experiment{other}
+			if test.resumed {
+				resumed := experiment
+				resumed.Try = test.try
+				want = []types.Experiment{resumed, other}
+			}
+			if len(remaining) != len(want) {
+				t.Fatalf("resumed %d experiments, want %d", len(remaining), len(want))
+			}
+			for i := range want {
+				if remaining[i] != want[i] {
+					t.Errorf("resumed %v with try %d, want %v with try %d", remaining[i].ToFileName(), remaining[i].Try, want[i].ToFileName(), want[i].Try)
+				}
+			}
+
+			entry := journal.Entry(experiment)
+			if entry.State != test.state || entry.Tries != test.try {
+				t.Errorf("journal has %s after %d tries, want %s after %d", entry.State, entry.Tries, test.state, test.try)
+			}
+			if entry := journal.Entry(other); entry.State != StatePending || entry.Tries != 0 {
+				t.Errorf("journal has %s for an experiment which never ran", entry.State)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package evaluation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"prinkbenchmarking/src/dataset"
+	"prinkbenchmarking/src/prink"
+	"prinkbenchmarking/src/types"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Outcomes of an experiment in its manifest
+const (
+	OutcomeRunning   = "running"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	// OutcomeInterrupted experiments were stopped by a shutdown of the client
+	OutcomeInterrupted = "interrupted"
+	// OutcomeTimedOut experiments were cancelled after their experiment_timeout
+	OutcomeTimedOut = "timed_out"
+)
+
+// Manifest records the provenance of one experiment run, so its results can be reproduced
+// without relying on the parameters encoded in the file names.
+type Manifest struct {
+	Name       string           `json:"name"`
+	Experiment types.Experiment `json:"experiment"`
+	Config     types.Config     `json:"config"`
+
+	Client  ClientInfo     `json:"client"`
+	Host    HostInfo       `json:"host"`
+	Sut     *prink.SutInfo `json:"sut,omitempty"`
+	Dataset DatasetInfo    `json:"dataset"`
+
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// StartupSeconds is the time from starting the SUT until the Prink job was running
+	StartupSeconds float64 `json:"startup_seconds,omitempty"`
+	// Try is the number of earlier tries of the experiment
+	Try     int    `json:"try"`
+	Outcome string `json:"outcome"`
+	// Errors lists the provenance which could not be collected
+	Errors []string `json:"errors,omitempty"`
+
+	path string
+}
+
+// ClientInfo identifies the build of the client.
+type ClientInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	CommitAt  string `json:"commit_time"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"go_version"`
+}
+
+// HostInfo describes the machine the client runs on.
+type HostInfo struct {
+	Hostname    string `json:"hostname"`
+	CPUModel    string `json:"cpu_model"`
+	CPUs        int    `json:"cpus"`
+	MemoryBytes int64  `json:"memory_bytes"`
+	Kernel      string `json:"kernel"`
+	OS          string `json:"os"`
+	Arch        string `json:"arch"`
+}
+
+// DatasetInfo identifies the input data of the experiment.
+type DatasetInfo struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// newManifest returns the manifest of an experiment starting now and writes it with the outcome running.
+func newManifest(experiment *types.Experiment, config types.Config) *Manifest {
+	m := &Manifest{
+		Name:       experiment.ToFileName(),
+		Experiment: *experiment,
+		Config:     config,
+		Client:     clientInfo(),
+		Host:       hostInfo(),
+		Dataset:    DatasetInfo{Path: config.InputData},
+		Start:      time.Now(),
+		Try:        experiment.Try,
+		Outcome:    OutcomeRunning,
+	}
+
+	folder := fmt.Sprintf("%s/%d", config.OutputFolder, experiment.RunId)
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		log.Printf("Could not create output directory: %v", err)
+	}
+	m.path = fmt.Sprintf("%s/manifest.%s.%s.json", folder, m.Start.Format("2006-01-02_15:04:05"), m.Name)
+
+	checksum, err := dataset.Shared(config.InputData, config.DatasetCacheMB).Checksum()
+	if err != nil {
+		m.Errors = append(m.Errors, err.Error())
+	}
+	m.Dataset.SHA256 = checksum
+
+	if err := m.save(); err != nil {
+		log.Printf("Could not save manifest: %v", err)
+	}
+	return m
+}
+
+// finish records the end and outcome of the experiment and the SUT it ran on, and writes the manifest.
+func (m *Manifest) finish(experiment *types.Experiment, config types.Config, outcome string) {
+	m.End = time.Now()
+	m.Outcome = outcome
+
+	if config.SutMode == "docker" {
+		sut, err := prink.DescribeSut(experiment, config)
+		if err != nil {
+			m.Errors = append(m.Errors, fmt.Sprintf("could not describe SUT: %v", err))
+		}
+		m.Sut = sut
+	}
+
+	if err := m.save(); err != nil {
+		log.Printf("Could not save manifest: %v", err)
+	}
+}
+
+func (m *Manifest) save() error {
+	file, err := os.Create(m.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
+
+func clientInfo() ClientInfo {
+	info := ClientInfo{GoVersion: runtime.Version()}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Version = build.Main.Version
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.CommitAt = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+func hostInfo() HostInfo {
+	info := HostInfo{
+		CPUs: runtime.NumCPU(),
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+	}
+	info.Hostname, _ = os.Hostname()
+
+	if kernel, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		info.Kernel = strings.TrimSpace(string(kernel))
+	}
+	info.CPUModel = procValue("/proc/cpuinfo", "model name")
+	// MemTotal is given in kB
+	if memory, err := strconv.ParseInt(strings.TrimSuffix(procValue("/proc/meminfo", "MemTotal"), " kB"), 10, 64); err == nil {
+		info.MemoryBytes = memory * 1024
+	}
+	return info
+}
+
+// procValue returns the first value of the key in a "key: value" file of /proc, or "" if it is not available.
+func procValue(path string, key string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, found := strings.Cut(scanner.Text(), ":")
+		if found && strings.TrimSpace(name) == key {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package evaluation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"prinkbenchmarking/src/types"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	data := []byte("id;timestamp\n1;2016-01-01 00:00:00\n")
+	sum := sha256.Sum256(data)
+
+	tests := []struct {
+		name    string
+		dataset bool
+		try     int
+		outcome string
+		// whether the checksum could not be computed
+		wantErrors bool
+	}{
+		{name: "succeeded", dataset: true, outcome: OutcomeSucceeded},
+		{name: "retried", dataset: true, try: 2, outcome: OutcomeFailed},
+		{name: "dataset missing", dataset: false, outcome: OutcomeFailed, wantErrors: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			folder := t.TempDir()
+			config := types.Config{OutputFolder: folder, InputData: folder + "/data.csv", SutMode: "fake"}
+			if test.dataset {
+				if err := os.WriteFile(config.InputData, data, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			experiment := types.DefaultExperiment()
+			experiment.Try = test.try
+
+			m := newManifest(&experiment, config)
+			if saved := readManifest(t, m.path); saved.Outcome != OutcomeRunning {
+				t.Errorf("saved outcome %q before the experiment finished, want %q", saved.Outcome, OutcomeRunning)
+			}
+
+			m.finish(&experiment, config, test.outcome)
+			saved := readManifest(t, m.path)
+			if saved.Outcome != test.outcome || saved.Try != test.try || saved.Name != experiment.ToFileName() {
+				t.Errorf("saved %s with outcome %q and try %d", saved.Name, saved.Outcome, saved.Try)
+			}
+			if saved.End.Before(saved.Start) {
+				t.Errorf("experiment ended at %v before it started at %v", saved.End, saved.Start)
+			}
+			if test.wantErrors {
+				if len(saved.Errors) == 0 || saved.Dataset.SHA256 != "" {
+					t.Errorf("got checksum %q and errors %v, want an error", saved.Dataset.SHA256, saved.Errors)
+				}
+			} else if saved.Dataset.SHA256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("got checksum %q, want %x", saved.Dataset.SHA256, sum)
+			}
+		})
+	}
+}
+
+func readManifest(t *testing.T, path string) Manifest {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestProcValue(t *testing.T) {
+	path := t.TempDir() + "/meminfo"
+	content := "MemTotal:       16384 kB\nMemFree:  1024 kB\nmodel name\t: Some CPU @ 2.00GHz\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		key  string
+		want string
+	}{
+		{name: "first key", path: path, key: "MemTotal", want: "16384 kB"},
+		{name: "tab before colon", path: path, key: "model name", want: "Some CPU @ 2.00GHz"},
+		{name: "missing key", path: path, key: "SwapTotal", want: ""},
+		{name: "missing file", path: path + ".missing", key: "MemTotal", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := procValue(test.path, test.key); got != test.want {
+				t.Errorf("procValue(%s) = %q, want %q", test.key, got, test.want)
+			}
+		})
+	}
+}
@@ -2,24 +2,52 @@ package evaluation
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"prinkbenchmarking/src/analysis"
 	"prinkbenchmarking/src/exporter"
 	"prinkbenchmarking/src/types"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func handleReadConnection(conn net.Conn, config types.Config, experiment *types.Experiment) error {
+// resultColumns returns the header of the results files: the time a record was read and its phase,
+// followed by the fields of the records returned by Prink. These are the dataset's columns,
+// the benchmark fields appended by the client and the timing and info loss fields appended by Prink.
+func resultColumns(schema types.Schema) []string {
+	columns := append([]string{"t_e", "phase"}, schema.Names()...)
+	return append(columns, "m_id", "t_s", "t_bs", "t_bse", "t_d", "t_de", "info_loss")
+}
+
+func handleReadConnection(conn net.Conn, phases *phases, config types.Config, experiment *types.Experiment) error {
 	// close connection when done
 
-	writer, file := initialiseResults(config.OutputFolder, experiment)
+	columns := resultColumns(config.Schema)
+	writer, file := initialiseResults(config.OutputFolder, experiment, columns)
 	defer file.Close()
 	defer writer.Flush()
 	log.Printf("Reading from connection")
 
+	// check the anonymity of the output once the connection is closed
+	header := analysis.NewHeader(columns[2:])
+	verifier := analysis.NewVerifier(*experiment, config.Schema)
+	defer func() {
+		report := verifier.Report()
+		log.Printf("Verification of %v: %v", experiment, report)
+		if err := analysis.SaveVerificationReport(config.OutputFolder, experiment, report); err != nil {
+			log.Printf("Could not save verification report: %v", err)
+		}
+	}()
+
+	transfer, err := exporter.NewTransfer(experiment)
+	if err != nil {
+		return err
+	}
+
 	reader := bufio.NewScanner(conn)
 
 	// Read the data
@@ -28,9 +56,23 @@ func handleReadConnection(conn net.Conn, config types.Config, experiment *types.
 
 		// Export record as prometheus Gauge
 		record := strings.Split(response, ";")
-		exporter.ExportRecordAsPrometheusGaugePrink(record, experiment)
+		fields := header.Record(record)
+		phase := types.PhaseMeasurement
+		if mId, err := strconv.Atoi(fields.Get("m_id")); err == nil {
+			phase = phases.of(mId)
+		}
+		received := time.Now()
+		transfer.RecordReceived(len(response) + 1)
+		if phase == types.PhaseMeasurement {
+			exporter.ExportRecordAsPrometheusGaugePrink(record, experiment)
+			if sent, err := fields.Time("t_s"); err == nil {
+				transfer.ObserveLatency(received.Sub(sent))
+			}
+		}
+		// anonymity is verified for all records, not only the measured ones
+		verifier.Add(fields)
 
-		output := fmt.Sprintf("%v; %s\n", time.Now(), response)
+		output := fmt.Sprintf("%v; %s; %s\n", received, phase, response)
 		_, err := writer.Write([]byte(output))
 		if err != nil {
 			return fmt.Errorf("could not write to buffer: %v", err)
@@ -44,27 +86,29 @@ func handleReadConnection(conn net.Conn, config types.Config, experiment *types.
 	return nil
 }
 
-func readSocketConnection(e *types.Experiment, config types.Config) error {
+func readSocketConnection(ctx context.Context, e *types.Experiment, phases *phases, config types.Config) error {
 	// Open socket connection
 	ln, err := net.Listen("tcp", fmt.Sprintf("%s:%d", "0.0.0.0", e.SutPortRead))
-	ln.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Minute))
 	if err != nil {
-		return fmt.Errorf("could not open read socket connection: %v", err)
+		return listenError("could not open read socket connection", err)
 	}
+	ln.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Minute))
 	defer ln.Close()
+	defer closeOnDone(ctx, ln)()
 
 	// Accept connection
 	conn, err := ln.Accept()
 	if err != nil {
-		return fmt.Errorf("could not accept read connection: %v", err)
+		return acceptError("could not accept read connection", err)
 	}
 	defer conn.Close()
+	defer closeOnDone(ctx, conn)()
 
 	// Handle connection
-	return handleReadConnection(conn, config, e)
+	return handleReadConnection(conn, phases, config, e)
 }
 
-func initialiseResults(path string, experiment *types.Experiment) (*bufio.Writer, *os.File) {
+func initialiseResults(path string, experiment *types.Experiment, columns []string) (*bufio.Writer, *os.File) {
 	path = fmt.Sprintf("%s/%d", path, experiment.RunId)
 
 	// Create the directory if it doesn't exist
@@ -90,7 +134,7 @@ func initialiseResults(path string, experiment *types.Experiment) (*bufio.Writer
 	// Write header if the file is empty
 	if info.Size() == 0 {
 
-		_, err = writer.WriteString(strings.Join([]string{"t_e", "building_id", "timestamp", "meter_reading", "primary_use", "square_feet", "year_built", "floor_count", "air_temperature", "cloud_coverage", "dew_temperature", "precip_depth_1_hr", "sea_level_pressure", "wind_direction", "wind_speed", "building_id2", "unixTimestamp",  "m_id", "t_s", "t_bs", "t_bse", "t_d", "t_de", "info_loss"}, ";"))
+		_, err = writer.WriteString(strings.Join(columns, ";"))
 		writer.Write([]byte("\n"))
 		if err != nil {
 			log.Fatalf("Could not write to results.csv: %v", err)
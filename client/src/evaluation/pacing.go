@@ -0,0 +1,118 @@
+package evaluation
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"prinkbenchmarking/src/types"
+	"time"
+)
+
+// pacer computes the intended send times of an open-loop load.
+// The schedule only depends on the rate and the load profile, never on when records were actually sent,
+// so a slow SUT cannot throttle the offered load.
+type pacer struct {
+	rate    float64
+	profile types.LoadProfile
+	// intended send time of the next record relative to the start of the run
+	offset time.Duration
+}
+
+func newPacer(rate int, profile types.LoadProfile) *pacer {
+	return &pacer{
+		rate:    float64(rate),
+		profile: profile,
+	}
+}
+
+// factor returns the factor of the rate the load profile prescribes at the given offset.
+func (p *pacer) factor(offset time.Duration) float64 {
+	switch p.profile.Shape {
+	case "step":
+		step := int(offset / p.profile.StepDuration)
+		if step >= len(p.profile.Steps) {
+			step = len(p.profile.Steps) - 1
+		}
+		return p.profile.Steps[step]
+	case "ramp":
+		if offset >= p.profile.RampDuration {
+			return 1
+		}
+		progress := float64(offset) / float64(p.profile.RampDuration)
+		return p.profile.RampStart + (1-p.profile.RampStart)*progress
+	}
+	return 1
+}
+
+// next returns the intended send time of the next record and the target rate at that time.
+func (p *pacer) next() (time.Duration, float64) {
+	current := p.offset
+	// never drop below one record per second, otherwise a ramp starting at 0 would never start
+	rate := math.Max(p.rate*p.factor(current), 1)
+	p.offset += time.Duration(float64(time.Second) / rate)
+	return current, rate
+}
+
+type pacingSecond struct {
+	targetRate float64
+	intended   int
+	sent       int
+	lagSum     time.Duration
+	lagMax     time.Duration
+}
+
+// pacingStats accounts intended and actual send times per second of the run.
+// Records are attributed to the second of their intended send time for the lag
+// and to the second of their actual send time for the number of sent records.
+type pacingStats struct {
+	seconds []pacingSecond
+}
+
+func (s *pacingStats) second(offset time.Duration) *pacingSecond {
+	i := int(offset / time.Second)
+	for len(s.seconds) <= i {
+		s.seconds = append(s.seconds, pacingSecond{})
+	}
+	return &s.seconds[i]
+}
+
+func (s *pacingStats) add(intended time.Duration, actual time.Duration, targetRate float64) {
+	lag := actual - intended
+
+	second := s.second(intended)
+	second.targetRate = targetRate
+	second.intended++
+	second.lagSum += lag
+	if lag > second.lagMax {
+		second.lagMax = lag
+	}
+
+	s.second(actual).sent++
+}
+
+// save writes the per-second accounting next to the results of the experiment.
+func (s *pacingStats) save(path string, experiment *types.Experiment) error {
+	path = fmt.Sprintf("%s/%d", path, experiment.RunId)
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return fmt.Errorf("could not create output directory: %v", err)
+	}
+
+	file, err := os.Create(path + "/pacing." + time.Now().Format("2006-01-02_15:04:05") + "." + experiment.ToFileName() + ".csv")
+	if err != nil {
+		return fmt.Errorf("could not open pacing file: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	fmt.Fprintln(writer, "second;target_rate;intended;sent;mean_lag_ms;max_lag_ms")
+	for i, second := range s.seconds {
+		meanLag := 0.0
+		if second.intended > 0 {
+			meanLag = float64(second.lagSum.Microseconds()) / float64(second.intended) / 1000
+		}
+		fmt.Fprintf(writer, "%d;%.1f;%d;%d;%.3f;%.3f\n", i, second.targetRate, second.intended, second.sent, meanLag, float64(second.lagMax.Microseconds())/1000)
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,103 @@
+package evaluation
+
+import (
+	"prinkbenchmarking/src/types"
+	"testing"
+	"time"
+)
+
+func TestPacerFactor(t *testing.T) {
+	step := types.LoadProfile{Shape: "step", StepDuration: 10 * time.Second, Steps: []float64{0.5, 1, 2}}
+	ramp := types.LoadProfile{Shape: "ramp", RampDuration: 10 * time.Second, RampStart: 0.2}
+
+	tests := []struct {
+		name    string
+		profile types.LoadProfile
+		offset  time.Duration
+		want    float64
+	}{
+		{name: "constant", profile: types.LoadProfile{}, offset: time.Minute, want: 1},
+		{name: "first step", profile: step, offset: 0, want: 0.5},
+		{name: "end of first step", profile: step, offset: 10*time.Second - 1, want: 0.5},
+		{name: "second step", profile: step, offset: 10 * time.Second, want: 1},
+		{name: "last step is kept", profile: step, offset: time.Hour, want: 2},
+		{name: "ramp start", profile: ramp, offset: 0, want: 0.2},
+		{name: "half of the ramp", profile: ramp, offset: 5 * time.Second, want: 0.6},
+		{name: "after the ramp", profile: ramp, offset: 20 * time.Second, want: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newPacer(100, test.profile)
+			if got := p.factor(test.offset); got < test.want-1e-9 || got > test.want+1e-9 {
+				t.Errorf("factor(%v) = %v, want %v", test.offset, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPacerNext(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    int
+		profile types.LoadProfile
+		// intended send times and target rates of the first records
+		want  []time.Duration
+		rates []float64
+	}{
+		{
+			name:  "constant rate",
+			rate:  10,
+			want:  []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond},
+			rates: []float64{10, 10, 10},
+		},
+		{
+			name:    "rate doubles with the step",
+			rate:    10,
+			profile: types.LoadProfile{Shape: "step", StepDuration: 200 * time.Millisecond, Steps: []float64{1, 2}},
+			want:    []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 250 * time.Millisecond},
+			rates:   []float64{10, 10, 20, 20},
+		},
+		{
+			name:    "ramp from zero starts at one record per second",
+			rate:    10,
+			profile: types.LoadProfile{Shape: "ramp", RampDuration: time.Hour},
+			want:    []time.Duration{0, time.Second},
+			rates:   []float64{1, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newPacer(test.rate, test.profile)
+			for i := range test.want {
+				offset, rate := p.next()
+				if offset != test.want[i] || rate != test.rates[i] {
+					t.Errorf("record %d: got %v at %v, want %v at %v", i, rate, offset, test.rates[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPacingStats(t *testing.T) {
+	stats := &pacingStats{}
+	// intended in second 0, sent in second 1 with a lag of 600ms
+	stats.add(500*time.Millisecond, 1100*time.Millisecond, 10)
+	stats.add(900*time.Millisecond, 1000*time.Millisecond, 10)
+	stats.add(2*time.Second, 2*time.Second, 20)
+
+	want := []pacingSecond{
+		{targetRate: 10, intended: 2, sent: 0, lagSum: 700 * time.Millisecond, lagMax: 600 * time.Millisecond},
+		{sent: 2},
+		{targetRate: 20, intended: 1, sent: 1},
+	}
+	if len(stats.seconds) != len(want) {
+		t.Fatalf("got %d seconds, want %d", len(stats.seconds), len(want))
+	}
+	for i := range want {
+		if stats.seconds[i] != want[i] {
+			t.Errorf("second %d: got %+v, want %+v", i, stats.seconds[i], want[i])
+		}
+	}
+}
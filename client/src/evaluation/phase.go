@@ -0,0 +1,113 @@
+package evaluation
+
+import (
+	"log"
+	"math"
+	"prinkbenchmarking/src/dataset"
+	"prinkbenchmarking/src/types"
+	"sync/atomic"
+	"time"
+)
+
+// phases assigns the records of an experiment to the phases of the measurement window.
+// The sender fixes the phase of a record before writing it, so the reader can look it up by the record's m_id.
+// It also tracks the event time of the records sent, which the watermarks of the job are compared to.
+type phases struct {
+	window types.Window
+	// first m_id of the measurement, -1 during the warm-up
+	measurementStart atomic.Int64
+	// first m_id of the cool-down, known before the experiment starts
+	cooldownStart int64
+	// m_id of the record sent last, -1 before the first one
+	sent atomic.Int64
+	// newest event time of the records sent in milliseconds, math.MinInt64 before the first one
+	eventTime atomic.Int64
+}
+
+func newPhases(window types.Window, cooldownStart int64) *phases {
+	p := &phases{window: window, cooldownStart: cooldownStart}
+	p.measurementStart.Store(-1)
+	p.sent.Store(-1)
+	p.eventTime.Store(math.MinInt64)
+	return p
+}
+
+// observeEventTime records the event time of a record sent, the records need not be ordered by it.
+func (p *phases) observeEventTime(ts time.Time) {
+	if ms := ts.UnixMilli(); ms > p.eventTime.Load() {
+		p.eventTime.Store(ms)
+	}
+}
+
+// begin returns the phase of the record with the m_id count, sent elapsed after the first record.
+func (p *phases) begin(count int, elapsed time.Duration) string {
+	if p.measurementStart.Load() < 0 && count >= p.window.WarmupRecords && elapsed >= p.window.WarmupDuration {
+		p.measurementStart.Store(int64(count))
+	}
+	p.sent.Store(int64(count))
+	return p.of(count)
+}
+
+// measuring reports whether the records currently sent belong to the measurement phase.
+func (p *phases) measuring() bool {
+	sent := p.sent.Load()
+	return sent >= 0 && p.of(int(sent)) == types.PhaseMeasurement
+}
+
+// newExperimentPhases returns the phases of the experiment according to the configured window.
+func newExperimentPhases(experiment *types.Experiment, config types.Config) (*phases, error) {
+	cooldown, err := cooldownStart(dataset.Shared(config.InputData, config.DatasetCacheMB), experiment, config)
+	if err != nil {
+		return nil, err
+	}
+	return newPhases(config.Window, cooldown), nil
+}
+
+// of returns the phase of the record with the m_id. The record must have been sent already.
+func (p *phases) of(mId int) string {
+	start := p.measurementStart.Load()
+	switch {
+	case int64(mId) >= p.cooldownStart:
+		return types.PhaseCooldown
+	case start < 0 || int64(mId) < start:
+		return types.PhaseWarmup
+	}
+	return types.PhaseMeasurement
+}
+
+// cooldownStart returns the m_id of the first record in the cool-down of the experiment.
+func cooldownStart(data *dataset.Dataset, experiment *types.Experiment, config types.Config) (int64, error) {
+	window := config.Window
+	if window.CooldownRecords == 0 && window.CooldownDuration == 0 {
+		return math.MaxInt64, nil
+	}
+
+	count, err := data.Count()
+	if err != nil {
+		return 0, err
+	}
+
+	start := int64(count - window.CooldownRecords)
+	if window.CooldownDuration == 0 {
+		return start, nil
+	}
+	if experiment.Rate == 0 {
+		log.Printf("Ignoring the cool-down duration of %v, it needs a rate", experiment)
+		return start, nil
+	}
+
+	// the schedule of the pacer is deterministic, so the intended send times can be computed in advance
+	var end time.Duration
+	schedule := newPacer(experiment.Rate, config.LoadProfile)
+	for i := 0; i < count; i++ {
+		end, _ = schedule.next()
+	}
+
+	schedule = newPacer(experiment.Rate, config.LoadProfile)
+	for i := int64(0); i < start; i++ {
+		if intended, _ := schedule.next(); intended >= end-window.CooldownDuration {
+			return i, nil
+		}
+	}
+	return start, nil
+}
@@ -0,0 +1,133 @@
+package evaluation
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"prinkbenchmarking/src/dataset"
+	"prinkbenchmarking/src/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPhases(t *testing.T) {
+	type send struct {
+		count   int
+		elapsed time.Duration
+		want    string
+	}
+
+	tests := []struct {
+		name          string
+		window        types.Window
+		cooldownStart int64
+		sends         []send
+	}{
+		{
+			name:          "no window",
+			cooldownStart: math.MaxInt64,
+			sends:         []send{{0, 0, types.PhaseMeasurement}, {1, time.Second, types.PhaseMeasurement}},
+		},
+		{
+			name:          "warm-up records",
+			window:        types.Window{WarmupRecords: 2},
+			cooldownStart: math.MaxInt64,
+			sends:         []send{{0, 0, types.PhaseWarmup}, {1, 0, types.PhaseWarmup}, {2, 0, types.PhaseMeasurement}},
+		},
+		{
+			name:          "warm-up lasts the records and the duration",
+			window:        types.Window{WarmupRecords: 1, WarmupDuration: time.Second},
+			cooldownStart: math.MaxInt64,
+			sends: []send{
+				{0, 0, types.PhaseWarmup},
+				{1, 500 * time.Millisecond, types.PhaseWarmup},
+				{2, time.Second, types.PhaseMeasurement},
+				{3, 2 * time.Second, types.PhaseMeasurement},
+			},
+		},
+		{
+			name:          "cool-down",
+			window:        types.Window{WarmupRecords: 1, CooldownRecords: 2},
+			cooldownStart: 3,
+			sends: []send{
+				{0, 0, types.PhaseWarmup},
+				{1, 0, types.PhaseMeasurement},
+				{2, 0, types.PhaseMeasurement},
+				{3, 0, types.PhaseCooldown},
+				{4, 0, types.PhaseCooldown},
+			},
+		},
+		{
+			name:          "cool-down before the warm-up ended",
+			window:        types.Window{WarmupRecords: 5},
+			cooldownStart: 2,
+			sends:         []send{{0, 0, types.PhaseWarmup}, {1, 0, types.PhaseWarmup}, {2, 0, types.PhaseCooldown}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newPhases(test.window, test.cooldownStart)
+			if p.measuring() {
+				t.Errorf("measuring before the first record")
+			}
+			for _, send := range test.sends {
+				if got := p.begin(send.count, send.elapsed); got != send.want {
+					t.Errorf("record %d sent after %v is in %s, want %s", send.count, send.elapsed, got, send.want)
+				}
+				if measuring := send.want == types.PhaseMeasurement; p.measuring() != measuring {
+					t.Errorf("measuring is %v after record %d, want %v", p.measuring(), send.count, measuring)
+				}
+			}
+			// the phase of a record does not change once it was sent
+			for _, send := range test.sends {
+				if got := p.of(send.count); got != send.want {
+					t.Errorf("record %d is in %s afterwards, want %s", send.count, got, send.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCooldownStart(t *testing.T) {
+	// a dataset of 100 records
+	path := filepath.Join(t.TempDir(), "data.csv")
+	lines := []string{"id,value"}
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("%d,%d", i, i))
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data := dataset.Shared(path, 1)
+
+	tests := []struct {
+		name   string
+		window types.Window
+		rate   int
+		want   int64
+	}{
+		{name: "no cool-down", window: types.Window{}, want: math.MaxInt64},
+		{name: "records", window: types.Window{CooldownRecords: 10}, want: 90},
+		// at 10 records per second the last record is intended after 9.9s, the cool-down starts at 7.9s
+		{name: "duration", window: types.Window{CooldownDuration: 2 * time.Second}, rate: 10, want: 79},
+		{name: "more records than the duration", window: types.Window{CooldownRecords: 30, CooldownDuration: time.Second}, rate: 10, want: 70},
+		{name: "more duration than the records", window: types.Window{CooldownRecords: 5, CooldownDuration: time.Second}, rate: 10, want: 89},
+		{name: "duration without rate", window: types.Window{CooldownRecords: 5, CooldownDuration: time.Second}, want: 95},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			experiment := &types.Experiment{Rate: test.rate}
+			got, err := cooldownStart(data, experiment, types.Config{Window: test.window})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("cool-down starts at %d, want %d", got, test.want)
+			}
+		})
+	}
+}
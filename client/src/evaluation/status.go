@@ -0,0 +1,62 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"prinkbenchmarking/src/types"
+	"time"
+)
+
+// States of a campaign in its status
+const (
+	CampaignCompleted   = "completed"
+	CampaignInterrupted = "interrupted"
+)
+
+// CampaignStatus summarizes a run of the client over the experiments of a campaign,
+// so an interrupted campaign shows which experiments are done and which are left.
+type CampaignStatus struct {
+	Campaign string    `json:"campaign"`
+	State    string    `json:"state"`
+	Reason   string    `json:"reason,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+
+	// number of the run's experiments per journal state
+	Experiments int                     `json:"experiments"`
+	States      map[ExperimentState]int `json:"states"`
+	// Remaining are the experiments which have not succeeded and are run again on resume
+	Remaining []string `json:"remaining,omitempty"`
+}
+
+// NewCampaignStatus returns the status of the run over the experiments according to the journal.
+func NewCampaignStatus(journal *Journal, experiments []types.Experiment, retry types.Retry) CampaignStatus {
+	status := CampaignStatus{
+		State:       CampaignCompleted,
+		Experiments: len(experiments),
+		States:      map[ExperimentState]int{},
+		End:         time.Now(),
+	}
+	for _, experiment := range experiments {
+		entry := journal.Entry(experiment)
+		status.States[entry.State]++
+		if entry.Remaining(retry) {
+			status.Remaining = append(status.Remaining, entry.Experiment)
+		}
+	}
+	return status
+}
+
+// Save writes the status as campaign_status.json into the output folder.
+func (s CampaignStatus) Save(folder string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(folder, "campaign_status.json"), data, 0644)
+}
@@ -0,0 +1,114 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"prinkbenchmarking/src/types"
+	"slices"
+	"testing"
+)
+
+func TestCampaignStatus(t *testing.T) {
+	retry := types.Retry{RetryPolicy: types.RetryPolicy{MaxTries: 2}}
+
+	experiments := []types.Experiment{}
+	for _, k := range []int{5, 10, 20, 40} {
+		e := types.DefaultExperiment()
+		e.K = k
+		experiments = append(experiments, e)
+	}
+	name := func(i int) string { return experiments[i].ToFileName() }
+
+	start := func(j *Journal, e types.Experiment) error { return j.Start(e) }
+	succeed := func(j *Journal, e types.Experiment) error { return j.Finish(e, nil) }
+	fail := func(j *Journal, e types.Experiment) error { return j.Finish(e, errors.New("failed")) }
+	interrupt := func(j *Journal, e types.Experiment) error { return j.Interrupt(e) }
+
+	tests := []struct {
+		name string
+		// updates of the journal per experiment
+		tries         [][]func(j *Journal, e types.Experiment) error
+		wantStates    map[ExperimentState]int
+		wantRemaining []string
+	}{
+		{
+			name:          "not started",
+			wantStates:    map[ExperimentState]int{StatePending: 4},
+			wantRemaining: []string{name(0), name(1), name(2), name(3)},
+		},
+		{
+			name: "completed",
+			tries: [][]func(*Journal, types.Experiment) error{
+				{start, succeed}, {start, succeed}, {start, fail, start, succeed}, {start, succeed},
+			},
+			wantStates: map[ExperimentState]int{StateSucceeded: 4},
+		},
+		{
+			name: "interrupted",
+			tries: [][]func(*Journal, types.Experiment) error{
+				{start, succeed}, {start, interrupt}, {start, fail}, {},
+			},
+			wantStates:    map[ExperimentState]int{StateSucceeded: 1, StateInterrupted: 1, StateFailed: 1, StatePending: 1},
+			wantRemaining: []string{name(1), name(2), name(3)},
+		},
+		{
+			name: "failed as often as allowed",
+			tries: [][]func(*Journal, types.Experiment) error{
+				{start, fail, start, fail}, {start, succeed}, {start, succeed}, {start, succeed},
+			},
+			wantStates: map[ExperimentState]int{StateFailed: 1, StateSucceeded: 3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			folder := t.TempDir()
+			journal, err := OpenJournal(folder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, tries := range test.tries {
+				for _, try := range tries {
+					if err := try(journal, experiments[i]); err != nil {
+						t.Fatalf("could not update journal: %v", err)
+					}
+				}
+			}
+
+			status := NewCampaignStatus(journal, experiments, retry)
+			if status.Experiments != len(experiments) {
+				t.Errorf("got %d experiments, want %d", status.Experiments, len(experiments))
+			}
+			if len(status.States) != len(test.wantStates) {
+				t.Errorf("got states %v, want %v", status.States, test.wantStates)
+			}
+			for state, count := range test.wantStates {
+				if status.States[state] != count {
+					t.Errorf("got states %v, want %v", status.States, test.wantStates)
+					break
+				}
+			}
+			if !slices.Equal(status.Remaining, test.wantRemaining) {
+				t.Errorf("got remaining %v, want %v", status.Remaining, test.wantRemaining)
+			}
+
+			status.State = CampaignInterrupted
+			status.Reason = "received interrupt"
+			if err := status.Save(folder); err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(folder + "/campaign_status.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var saved CampaignStatus
+			if err := json.Unmarshal(content, &saved); err != nil {
+				t.Fatal(err)
+			}
+			if saved.State != status.State || saved.Reason != status.Reason || !slices.Equal(saved.Remaining, status.Remaining) {
+				t.Errorf("saved status %+v, want %+v", saved, status)
+			}
+		})
+	}
+}
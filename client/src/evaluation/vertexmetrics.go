@@ -0,0 +1,88 @@
+package evaluation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"prinkbenchmarking/src/exporter"
+	"prinkbenchmarking/src/prink"
+	"prinkbenchmarking/src/types"
+	"strconv"
+	"time"
+)
+
+var vertexMetricsColumns = []string{"time", "second", "vertex_id", "vertex", "records_in", "records_out",
+	"backpressure_ratio", "busy_ratio", "idle_ratio", "watermark_lag_ms"}
+
+// collectVertexMetrics polls the metrics of the job's vertices every second until stop is closed,
+// writes them into vertex_metrics.*.csv and exports them through the Prometheus endpoint.
+func collectVertexMetrics(experiment *types.Experiment, phases *phases, config types.Config, stop <-chan bool) error {
+	folder := fmt.Sprintf("%s/%d", config.OutputFolder, experiment.RunId)
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return fmt.Errorf("could not create output directory: %v", err)
+	}
+	file, err := os.Create(folder + "/vertex_metrics." + time.Now().Format("2006-01-02_15:04:05") + "." + experiment.ToFileName() + ".csv")
+	if err != nil {
+		return fmt.Errorf("could not open vertex metrics file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Comma = ';'
+	defer writer.Flush()
+	if err := writer.Write(vertexMetricsColumns); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	start := time.Now()
+	var prevError string
+	for {
+		select {
+		case <-stop:
+			writer.Flush()
+			return writer.Error()
+		case now := <-ticker.C:
+			metrics, err := prink.GetVertexMetrics(experiment, config, phases.eventTime.Load())
+			if err != nil {
+				if err.Error() != prevError {
+					log.Println("Error in collecting vertex metrics: ", err)
+					prevError = err.Error()
+				}
+				continue
+			}
+
+			exporter.ExportVertexMetrics(metrics, experiment)
+			for _, m := range metrics {
+				row := []string{
+					now.Format(time.RFC3339Nano),
+					strconv.Itoa(int(now.Sub(start) / time.Second)),
+					m.ID,
+					m.Name,
+					formatMetric(m.RecordsIn),
+					formatMetric(m.RecordsOut),
+					formatMetric(m.BackpressureRatio),
+					formatMetric(m.BusyRatio),
+					formatMetric(m.IdleRatio),
+					formatMetric(m.WatermarkLagMs),
+				}
+				if err := writer.Write(row); err != nil {
+					return err
+				}
+			}
+			writer.Flush()
+		}
+	}
+}
+
+// formatMetric formats a metric value, unknown values are left empty.
+func formatMetric(value float64) string {
+	if math.IsNaN(value) {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
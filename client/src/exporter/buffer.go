@@ -0,0 +1,128 @@
+package exporter
+
+import (
+	"prinkbenchmarking/src/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Policies of a full sample buffer
+const (
+	// PolicyDropOldest replaces the oldest sample by the new one.
+	PolicyDropOldest = "drop_oldest"
+	// PolicyDropNewest discards new samples until the buffer is drained.
+	PolicyDropNewest = "drop_newest"
+	// PolicyDownsample discards every second buffered sample and keeps only every second new sample from then on.
+	PolicyDownsample = "downsample"
+)
+
+var droppedSamples = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "prink_exporter_dropped_samples_total",
+	Help: "Record samples dropped because the buffer of the metric was full",
+}, []string{"metric"})
+
+// sampleBuffer holds the samples of a record metric until the next scrape, at most capacity of them,
+// so the exporter cannot run out of memory if nobody scrapes it.
+type sampleBuffer struct {
+	policy   string
+	capacity int
+	// samples is a ring starting at start once it reached the capacity
+	samples []prinkMetricValue
+	start   int
+	// with the downsample policy only every stride-th offered sample is kept
+	stride  int
+	offered int
+
+	dropped prometheus.Counter
+}
+
+func newSampleBuffer(name string, config types.MetricBuffer) *sampleBuffer {
+	return &sampleBuffer{
+		policy:   config.Policy,
+		capacity: config.Capacity,
+		stride:   1,
+		dropped:  droppedSamples.WithLabelValues(name),
+	}
+}
+
+func (b *sampleBuffer) add(value prinkMetricValue) {
+	full := len(b.samples) >= b.capacity
+
+	switch b.policy {
+	case PolicyDropNewest:
+		if full {
+			b.dropped.Inc()
+			return
+		}
+	case PolicyDownsample:
+		b.offered++
+		if b.offered%b.stride != 0 {
+			b.dropped.Inc()
+			return
+		}
+		if full {
+			b.downsample()
+			// a buffer of one sample stays full
+			if len(b.samples) >= b.capacity {
+				b.dropped.Inc()
+				return
+			}
+		}
+	default:
+		if full {
+			b.samples[b.start] = value
+			b.start = (b.start + 1) % len(b.samples)
+			b.dropped.Inc()
+			return
+		}
+	}
+
+	b.samples = append(b.samples, value)
+}
+
+// downsample keeps every second sample and halves the rate of samples kept from now on.
+// The samples are counted from the one being added, so the kept samples stay evenly spaced.
+func (b *sampleBuffer) downsample() {
+	kept := b.samples[:0]
+	for i := 0; i < len(b.samples); i += 2 {
+		kept = append(kept, b.samples[i])
+	}
+	b.dropped.Add(float64(len(b.samples) - len(kept)))
+	b.samples = kept
+	b.stride *= 2
+	b.offered = 0
+}
+
+// requeue puts samples drained before back in front of the buffered ones. If not all of them fit,
+// the newest samples are dropped with PolicyDropNewest and the oldest with the other policies.
+func (b *sampleBuffer) requeue(samples []prinkMetricValue) {
+	samples = append(append([]prinkMetricValue{}, samples...), b.ordered()...)
+	if excess := len(samples) - b.capacity; excess > 0 {
+		if b.policy == PolicyDropNewest {
+			samples = samples[:b.capacity]
+		} else {
+			samples = samples[excess:]
+		}
+		b.dropped.Add(float64(excess))
+	}
+	b.samples = samples
+	b.start = 0
+}
+
+// ordered returns the buffered samples in the order they were added.
+func (b *sampleBuffer) ordered() []prinkMetricValue {
+	samples := make([]prinkMetricValue, 0, len(b.samples))
+	samples = append(samples, b.samples[b.start:]...)
+	return append(samples, b.samples[:b.start]...)
+}
+
+// drain returns the buffered samples in the order they were added and empties the buffer.
+func (b *sampleBuffer) drain() []prinkMetricValue {
+	samples := b.ordered()
+
+	b.samples = b.samples[:0]
+	b.start = 0
+	b.stride = 1
+	b.offered = 0
+	return samples
+}
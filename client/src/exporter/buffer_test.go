@@ -0,0 +1,110 @@
+package exporter
+
+import (
+	"prinkbenchmarking/src/types"
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// counterValue returns the current value of the counter.
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	if err := counter.Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+// values returns the values of the samples.
+func values(samples []prinkMetricValue) []float64 {
+	values := []float64{}
+	for _, sample := range samples {
+		values = append(values, sample.value)
+	}
+	return values
+}
+
+func TestSampleBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		capacity int
+		// values of the samples added
+		added   int
+		want    []float64
+		dropped float64
+	}{
+		{name: "below capacity", policy: PolicyDropOldest, capacity: 4, added: 3, want: []float64{0, 1, 2}},
+		{name: "drop oldest", policy: PolicyDropOldest, capacity: 4, added: 6, want: []float64{2, 3, 4, 5}, dropped: 2},
+		{name: "drop oldest wraps around", policy: PolicyDropOldest, capacity: 3, added: 8, want: []float64{5, 6, 7}, dropped: 5},
+		{name: "drop newest", policy: PolicyDropNewest, capacity: 4, added: 6, want: []float64{0, 1, 2, 3}, dropped: 2},
+		// the full buffer keeps 0 and 2, then every second sample is kept
+		{name: "downsample", policy: PolicyDownsample, capacity: 4, added: 7, want: []float64{0, 2, 4, 6}, dropped: 3},
+		// the second time the buffer is full only every fourth sample is kept
+		{name: "downsample twice", policy: PolicyDownsample, capacity: 4, added: 16, want: []float64{0, 4, 8, 12}, dropped: 12},
+		// downsampling a single sample frees no room for the new one
+		{name: "downsample capacity one", policy: PolicyDownsample, capacity: 1, added: 5, want: []float64{0}, dropped: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := "test_" + test.name
+			buffer := newSampleBuffer(name, types.MetricBuffer{Policy: test.policy, Capacity: test.capacity})
+			for i := 0; i < test.added; i++ {
+				buffer.add(prinkMetricValue{value: float64(i)})
+			}
+
+			if got := values(buffer.drain()); !slices.Equal(got, test.want) {
+				t.Errorf("drained %v, want %v", got, test.want)
+			}
+			if dropped := counterValue(t, droppedSamples.WithLabelValues(name)); dropped != test.dropped {
+				t.Errorf("dropped %v samples, want %v", dropped, test.dropped)
+			}
+			if got := buffer.drain(); len(got) != 0 {
+				t.Errorf("drained %d samples from the empty buffer", len(got))
+			}
+		})
+	}
+}
+
+func TestSampleBufferRequeue(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		capacity int
+		// values of the samples requeued and added meanwhile
+		requeued []float64
+		added    []float64
+		want     []float64
+		dropped  float64
+	}{
+		{name: "in front of new samples", policy: PolicyDropOldest, capacity: 5, requeued: []float64{0, 1}, added: []float64{2, 3}, want: []float64{0, 1, 2, 3}},
+		{name: "oldest dropped", policy: PolicyDropOldest, capacity: 3, requeued: []float64{0, 1}, added: []float64{2, 3}, want: []float64{1, 2, 3}, dropped: 1},
+		{name: "newest dropped", policy: PolicyDropNewest, capacity: 3, requeued: []float64{0, 1}, added: []float64{2, 3}, want: []float64{0, 1, 2}, dropped: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := "test_requeue_" + test.name
+			buffer := newSampleBuffer(name, types.MetricBuffer{Policy: test.policy, Capacity: test.capacity})
+			for _, value := range test.added {
+				buffer.add(prinkMetricValue{value: value})
+			}
+			requeued := []prinkMetricValue{}
+			for _, value := range test.requeued {
+				requeued = append(requeued, prinkMetricValue{value: value})
+			}
+			buffer.requeue(requeued)
+
+			if got := values(buffer.drain()); !slices.Equal(got, test.want) {
+				t.Errorf("drained %v, want %v", got, test.want)
+			}
+			if dropped := counterValue(t, droppedSamples.WithLabelValues(name)); dropped != test.dropped {
+				t.Errorf("dropped %v samples, want %v", dropped, test.dropped)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,7 +15,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var collector *prinkCollector = newPrinkCollector()
+// collector holds the record metrics, it is created by Configure from the schema of the dataset.
+var collector *prinkCollector
 
 type prinkMetricValue struct {
 	value float64
@@ -24,84 +26,191 @@ type prinkMetricValue struct {
 }
 
 type prinkMetric struct {
+	name string
+	labels []string
 	desc *prometheus.Desc
 	valueType prometheus.ValueType
-	values []prinkMetricValue
+	values *sampleBuffer
 	mtx sync.RWMutex
 }
 
-func newPrinkMetric(name string, help string, variableLabels []string) *prinkMetric {
+func newPrinkMetric(name string, help string, variableLabels []string, buffer types.MetricBuffer) *prinkMetric {
 	return &prinkMetric{
+		name: name,
+		labels: variableLabels,
 		desc: prometheus.NewDesc(name, help, variableLabels, nil),
 		valueType: prometheus.GaugeValue,
-		values: make([]prinkMetricValue, 0),
+		values: newSampleBuffer(name, buffer),
 	}
 }
 
+// drain returns the buffered samples of the metric and empties its buffer.
+func (metric *prinkMetric) drain() []prinkMetricValue {
+	metric.mtx.Lock()
+	defer metric.mtx.Unlock()
+	return metric.values.drain()
+}
+
+// requeue buffers samples which could not be delivered again, in front of the samples added meanwhile.
+func (metric *prinkMetric) requeue(samples []prinkMetricValue) {
+	metric.mtx.Lock()
+	defer metric.mtx.Unlock()
+	metric.values.requeue(samples)
+}
+
 func (metric *prinkMetric) Add(value float64, timestamp time.Time, labelValues []string, experiment *types.Experiment) {
 	metric.mtx.Lock()
 	defer metric.mtx.Unlock()
-	metric.values = append(metric.values, prinkMetricValue{value: value, timestamp: timestamp, labelValues: labelValues, experiment: experiment})
+	metric.values.add(prinkMetricValue{value: value, timestamp: timestamp, labelValues: labelValues, experiment: experiment})
 }
 
 
 type prinkCollector struct {
-	rawGaugeMeterReading *prinkMetric
-	rawGaugeSquareFeet *prinkMetric
-	prinkGaugeMeterReading *prinkMetric
-	prinkGaugeSquareFeet *prinkMetric
+	// pushing is set if the record metrics are pushed, scrapes then only return the other metrics
+	pushing atomic.Bool
+
+	timestampColumn int
+	labelColumns    []int
+	exportColumns   []int
+	// record metrics per exported column
+	raw   []*prinkMetric
+	prink []*prinkMetric
+	// layout of the timestamp column
+	layout string
 }
 
 
 //You must create a constructor for you collector that
 //initializes every descriptor and returns a pointer to the collector
-func newPrinkCollector() *prinkCollector {
-	keys := types.ExperimentKeys()
-
-	labels := append([]string{"building_id", "primary_use"}, keys...) 
-	return &prinkCollector{
-		rawGaugeMeterReading: newPrinkMetric("raw_gauge_meter_reading","P", labels),
-		rawGaugeSquareFeet: newPrinkMetric("raw_gauge_square_feet","P", labels),
-		prinkGaugeMeterReading: newPrinkMetric("prink_gauge_meter_reading","P", labels),
-		prinkGaugeSquareFeet: newPrinkMetric("prink_gauge_square_feet","P", labels),
+func newPrinkCollector(schema types.Schema, buffer types.MetricBuffer) *prinkCollector {
+	collector := &prinkCollector{layout: schema.Layout()}
+
+	labels := []string{}
+	for i, column := range schema.Columns {
+		if column.Label {
+			collector.labelColumns = append(collector.labelColumns, i)
+			labels = append(labels, column.Name)
+		}
+		if column.Role == types.RoleTimestamp {
+			collector.timestampColumn = i
+		}
 	}
+	labels = append(labels, types.ExperimentKeys()...)
+
+	for i, column := range schema.Columns {
+		if !column.Export {
+			continue
+		}
+		collector.exportColumns = append(collector.exportColumns, i)
+		collector.raw = append(collector.raw, newPrinkMetric("raw_gauge_"+column.Name, "Raw "+column.Name+" of the records sent to Prink", labels, buffer))
+		collector.prink = append(collector.prink, newPrinkMetric("prink_gauge_"+column.Name, "Anonymized "+column.Name+" of the records returned by Prink", labels, buffer))
+	}
+
+	return collector
 }
 
+func (collector *prinkCollector) metrics() []*prinkMetric {
+	return append(append([]*prinkMetric{}, collector.raw...), collector.prink...)
+}
 
+// Configure creates the record metrics for the exported columns of the schema,
+// each buffering its samples between two scrapes as configured by buffer.
+// It has to be called before the exporter is started and records are exported.
+func Configure(schema types.Schema, buffer types.MetricBuffer) {
+	collector = newPrinkCollector(schema, buffer)
+}
 
 //Each and every collector must implement the Describe function.
 //It essentially writes all descriptors to the prometheus desc channel.
 func (collector *prinkCollector) Describe(ch chan<- *prometheus.Desc) {
-
-	//Update this section with the each metric you create for a given collector
-	ch <- collector.rawGaugeMeterReading.desc
-	ch <- collector.rawGaugeSquareFeet.desc
-	ch <- collector.prinkGaugeMeterReading.desc
-	ch <- collector.prinkGaugeSquareFeet.desc
+	for _, metric := range collector.metrics() {
+		ch <- metric.desc
+	}
 }
 
 //Collect implements required collect function for all promehteus collectors
 func (collector *prinkCollector) Collect(ch chan<- prometheus.Metric) {
+	if collector.pushing.Load() {
+		return
+	}
 
-	for _, metric := range []*prinkMetric{collector.rawGaugeMeterReading, collector.rawGaugeSquareFeet, collector.prinkGaugeMeterReading, collector.prinkGaugeSquareFeet} {
-		metric.mtx.Lock()
-		for _, value := range metric.values {
-			ts := value.timestamp
-			normalizedTs := time.Date(time.Now().Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, ts.Location())
-
+	for _, metric := range collector.metrics() {
+		for _, value := range metric.drain() {
 			labels := append(value.labelValues, value.experiment.ToLabels()...)
 
 			m := prometheus.MustNewConstMetric(metric.desc, metric.valueType, value.value, labels...)
-			m = prometheus.NewMetricWithTimestamp(normalizedTs, m)
+			m = prometheus.NewMetricWithTimestamp(normalizeTimestamp(value.timestamp), m)
 			ch <- m
 		}
-		// clear values
-		metric.values = make([]prinkMetricValue, 0)
-		metric.mtx.Unlock()
 	}
 	
 }
 
+// normalizeTimestamp moves the event time of a record into the current year, so it is within the retention.
+func normalizeTimestamp(ts time.Time) time.Time {
+	return time.Date(time.Now().Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, ts.Location())
+}
+
+// export adds the exported columns of the record to the metrics, labelled with the label columns.
+func (collector *prinkCollector) export(metrics []*prinkMetric, record []string, experiment *types.Experiment) {
+	if len(record) <= collector.timestampColumn {
+		log.Printf("Record has only %d fields", len(record))
+		return
+	}
+
+	ts, err := time.Parse(collector.layout, record[collector.timestampColumn])
+	if err != nil {
+		log.Printf("Error converting timestamp to time: %v", err)
+		return
+	}
+
+	values := make([]float64, len(collector.exportColumns))
+	for i, column := range collector.exportColumns {
+		if column >= len(record) {
+			log.Printf("Record has only %d fields", len(record))
+			return
+		}
+		values[i], err = parseValue(record[column])
+		if err != nil {
+			log.Printf("Error converting %s to float: %v", record[column], err)
+			return
+		}
+	}
+
+	labels := make([]string, 0, len(collector.labelColumns))
+	for _, column := range collector.labelColumns {
+		if column >= len(record) {
+			log.Printf("Record has only %d fields", len(record))
+			return
+		}
+		labels = append(labels, record[column])
+	}
+
+	for i, value := range values {
+		metrics[i].Add(value, ts, labels, experiment)
+	}
+}
+
+// parseValue parses a numeric field. Fields generalized by Prink are tuples like (1,5),
+// which are converted into the mean of their entries.
+func parseValue(field string) (float64, error) {
+	if !strings.HasPrefix(field, "(") || !strings.HasSuffix(field, ")") {
+		return strconv.ParseFloat(field, 64)
+	}
+
+	// rm first and last character and split by ','
+	entries := strings.Split(field[1:len(field)-1], ",")
+	sum := 0.0
+	for _, entry := range entries {
+		value, err := strconv.ParseFloat(strings.TrimSpace(entry), 64)
+		if err != nil {
+			return 0, err
+		}
+		sum += value
+	}
+	return sum / float64(len(entries)), nil
+}
+
 
 func StartPrometheusExporter(addr string) {
 	reg := prometheus.NewPedanticRegistry()
@@ -113,6 +222,9 @@ func StartPrometheusExporter(addr string) {
 			collectors.WithGoCollectorRuntimeMetrics(collectors.GoRuntimeMetricsRule{Matcher: collectors.MetricsAll.Matcher}),
 		),
 	)
+	reg.MustRegister(vertexGauges()...)
+	reg.MustRegister(transferMetrics()...)
+	reg.MustRegister(droppedSamples, pushedSamples, startupTime)
 
 	// Expose /metrics HTTP endpoint using the created custom registry.
 	http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
@@ -121,76 +233,17 @@ func StartPrometheusExporter(addr string) {
 
 
 func ExportRecordAsPrometheusGaugeRaw(record []string, experiment *types.Experiment) {
-	// Data fields:
-	// building_id, timestamp, meter_reading, primary_use, square_feet, year_built, floor_count, air_temperature, cloud_coverage, dew_temperature, precip_depth_1_hr, sea_level_pressure, wind_direction, wind_speed, building_id2, unix_timestamp,
-
-	// Export record as prometheus Gauge
-	buildingID := record[0]
-	timestamp := record[1]
-	primaryUse := record[3]
-	meter_reading, err := strconv.ParseFloat(record[2], 64)
-	if err != nil {
-		log.Printf("Error converting meter reading to float: %v", err)
-		return
-	}
-
-	ts, err := time.Parse(time.DateTime, timestamp)
-	if err != nil {
-		log.Printf("Error converting timestamp to time: %v in ExportRecordAsPrometheusGaugeRaw", err)
+	// Data fields: the columns of the schema passed to Configure
+	if collector == nil {
 		return
 	}
-
-	square_feet, err := strconv.ParseFloat(record[4], 64)
-	if err != nil {
-		log.Printf("Error converting square feet to float: %v", err)
-		return
-	}
-
-	collector.rawGaugeMeterReading.Add(meter_reading, ts, []string{buildingID, primaryUse}, experiment)
-	collector.rawGaugeSquareFeet.Add(square_feet, ts, []string{buildingID, primaryUse}, experiment)
+	collector.export(collector.raw, record, experiment)
 }
 
 func ExportRecordAsPrometheusGaugePrink(record []string, experiment *types.Experiment) {
-	// Data fields:
-	// building_id, timestamp, meter_reading, primary_use, square_feet, year_built, floor_count, air_temperature, cloud_coverage, dew_temperature, precip_depth_1_hr, sea_level_pressure, wind_direction, wind_speed, building_id2, unix_timestamp,
-
-	// Export record as prometheus Gauge
-	buildingID := record[0]
-	timestamp := record[1]
-	primaryUse := record[3]
-	square_feet_tuple := record[4]
-
-	meter_reading, err := strconv.ParseFloat(record[2], 64)
-	if err != nil {
-		log.Printf("Error converting meter reading to float: %v", err)
+	// Data fields: the columns of the schema passed to Configure, generalized by Prink
+	if collector == nil {
 		return
 	}
-	
-	ts, err := time.Parse(time.DateTime, timestamp)
-	if err != nil {
-		log.Printf("Error converting timestamp to time: %v in ExportRecordAsPrometheusGaugeRaw", err)
-		return
-	}
-
-	// Convert Tuples into single values
-	// rm first and last character and split by ','
-	square_feet_tuple = square_feet_tuple[1 : len(square_feet_tuple)-1]
-	squre_feet_list := strings.Split(square_feet_tuple, ",")
-	square_feet_sum := 0.0
-
-	for _, square_feet_entry := range squre_feet_list {
-		square_feet_entry, err := strconv.ParseFloat(square_feet_entry, 64)
-		if err != nil {
-			log.Printf("Error converting square_feet to float: %v", err)
-			return
-		}
-		square_feet_sum += square_feet_entry
-	}
-	square_feet := square_feet_sum / float64(len(squre_feet_list))
-
-	// Expose the data as prometheus gauges
-	
-	collector.prinkGaugeMeterReading.Add(meter_reading, ts, []string{buildingID, primaryUse}, experiment)
-	collector.prinkGaugeSquareFeet.Add(square_feet, ts, []string{buildingID, primaryUse}, experiment)
+	collector.export(collector.prink, record, experiment)
 }
-
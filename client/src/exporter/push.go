@@ -0,0 +1,179 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"prinkbenchmarking/src/types"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var pushedSamples = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "prink_exporter_pushed_samples_total",
+	Help: "Record samples pushed to the import API, by result (ok or failed)",
+}, []string{"result"})
+
+// pusher ships the samples of the record metrics to the VictoriaMetrics import API
+// in the Prometheus text format, so samples are not lost if nobody scrapes the exporter.
+type pusher struct {
+	config types.Push
+	client *http.Client
+	// serialises the pushes, so a flush waits for a periodic push in progress
+	mtx    sync.Mutex
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+var activePusher *pusher
+
+// StartPusher pushes the record metrics to the configured URL instead of exposing them to scrapes.
+// Configure has to be called before.
+func StartPusher(config types.Push) {
+	if collector == nil {
+		return
+	}
+	activePusher = &pusher{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+		ticker: time.NewTicker(config.Interval),
+		stop:   make(chan struct{}),
+	}
+	collector.pushing.Store(true)
+
+	go func(p *pusher) {
+		for {
+			select {
+			case <-p.ticker.C:
+				if err := p.push(); err != nil {
+					log.Printf("Could not push metrics: %v", err)
+				}
+			case <-p.stop:
+				return
+			}
+		}
+	}(activePusher)
+}
+
+// StopPusher stops the periodic pushes and pushes the remaining samples.
+// It does nothing if no pusher was started.
+func StopPusher() error {
+	if activePusher == nil {
+		return nil
+	}
+	activePusher.ticker.Stop()
+	close(activePusher.stop)
+	return activePusher.push()
+}
+
+// Flush pushes the buffered samples immediately, after a periodic push in progress.
+// It does nothing if no pusher was started.
+func Flush() error {
+	if activePusher == nil {
+		return nil
+	}
+	return activePusher.push()
+}
+
+// push sends the buffered samples in batches. The samples of batches which could not be sent
+// are buffered again, as far as the buffers have room, and sent with the next push.
+func (p *pusher) push() error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	var batch bytes.Buffer
+	lines := 0
+	// samples of the current batch and of the batches which failed, per metric
+	samples := map[*prinkMetric][]prinkMetricValue{}
+	unsent := map[*prinkMetric][]prinkMetricValue{}
+	var failed error
+	send := func() {
+		if lines == 0 {
+			return
+		}
+		if err := p.send(batch.Bytes()); err != nil {
+			pushedSamples.WithLabelValues("failed").Add(float64(lines))
+			for metric, values := range samples {
+				unsent[metric] = append(unsent[metric], values...)
+			}
+			failed = err
+		} else {
+			pushedSamples.WithLabelValues("ok").Add(float64(lines))
+		}
+		batch.Reset()
+		lines = 0
+		clear(samples)
+	}
+
+	for _, metric := range collector.metrics() {
+		for _, value := range metric.drain() {
+			writeSample(&batch, metric, value)
+			samples[metric] = append(samples[metric], value)
+			lines++
+			if lines >= p.config.BatchSize {
+				send()
+			}
+		}
+	}
+	send()
+
+	for metric, values := range unsent {
+		metric.requeue(values)
+	}
+	return failed
+}
+
+// send posts a batch, retrying with exponential backoff.
+func (p *pusher) send(batch []byte) error {
+	backoff := 500 * time.Millisecond
+	var err error
+	for attempt := 0; attempt <= p.config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		var response *http.Response
+		response, err = p.client.Post(p.config.URL, "text/plain", bytes.NewReader(batch))
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		response.Body.Close()
+		if response.StatusCode/100 == 2 {
+			return nil
+		}
+		err = fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return fmt.Errorf("push to %s failed after %d attempts: %v", p.config.URL, p.config.Retries+1, err)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// writeSample writes the sample as a line of the Prometheus text format with its timestamp in milliseconds.
+func writeSample(w *bytes.Buffer, metric *prinkMetric, value prinkMetricValue) {
+	labels := append(append([]string{}, value.labelValues...), value.experiment.ToLabels()...)
+
+	w.WriteString(metric.name)
+	w.WriteByte('{')
+	for i, name := range metric.labels {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(name)
+		w.WriteString(`="`)
+		w.WriteString(labelValueEscaper.Replace(labels[i]))
+		w.WriteByte('"')
+	}
+	w.WriteString("} ")
+	w.WriteString(strconv.FormatFloat(value.value, 'g', -1, 64))
+	w.WriteByte(' ')
+	w.WriteString(strconv.FormatInt(normalizeTimestamp(value.timestamp).UnixMilli(), 10))
+	w.WriteByte('\n')
+}
@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"prinkbenchmarking/src/types"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// importServer records the bodies posted to it and fails the first failures requests.
+type importServer struct {
+	*httptest.Server
+	mtx      sync.Mutex
+	failures int
+	requests int
+	bodies   []string
+}
+
+func newImportServer(t *testing.T, failures int) *importServer {
+	s := &importServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		s.requests++
+		if s.requests <= s.failures {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		s.bodies = append(s.bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// testMetric configures the collector with one exported column and returns its raw metric.
+func testMetric(t *testing.T, capacity int) *prinkMetric {
+	schema := types.Schema{Columns: []types.Column{
+		{Name: "building_id", Type: "string", Role: types.RoleID, Label: true},
+		{Name: "timestamp", Type: "time", Role: types.RoleTimestamp},
+		{Name: "meter_reading", Type: "float", Export: true},
+	}}
+	Configure(schema, types.MetricBuffer{Policy: PolicyDropOldest, Capacity: capacity})
+	t.Cleanup(func() { collector = nil })
+	return collector.raw[0]
+}
+
+func TestPushLineFormat(t *testing.T) {
+	server := newImportServer(t, 0)
+	metric := testMetric(t, 10)
+	experiment := &types.Experiment{K: 5, Delta: 20, RunId: 1}
+	timestamp := time.Date(2016, 1, 1, 10, 30, 0, 0, time.UTC)
+	metric.Add(76.46, timestamp, []string{`a"b`}, experiment)
+
+	p := &pusher{config: types.Push{URL: server.URL, BatchSize: 10}, client: server.Client()}
+	if err := p.push(); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+
+	want := fmt.Sprintf(`raw_gauge_meter_reading{building_id="a\"b",k="5",delta="20",l="0",beta="0",zeta="0",mu="0",rate="0",taskmanagers="0",slots="0",parallelism="0",run_id="1"} 76.46 %d`+"\n",
+		normalizeTimestamp(timestamp).UnixMilli())
+	if len(server.bodies) != 1 || server.bodies[0] != want {
+		t.Errorf("pushed %q, want %q", server.bodies, want)
+	}
+}
+
+func TestPushBatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		samples   int
+		batchSize int
+		retries   int
+		failures  int
+		// lines of the received batches
+		want     []int
+		wantErr  bool
+		requests int
+		// samples left in the buffer after the push
+		buffered int
+	}{
+		{name: "one batch", samples: 3, batchSize: 10, want: []int{3}, requests: 1},
+		{name: "split into batches", samples: 5, batchSize: 2, want: []int{2, 2, 1}, requests: 3},
+		{name: "retried after failure", samples: 3, batchSize: 10, retries: 1, failures: 1, want: []int{3}, requests: 2},
+		{name: "buffered again after retries", samples: 3, batchSize: 10, retries: 1, failures: 2, wantErr: true, requests: 2, buffered: 3},
+		{name: "failed batch buffered again", samples: 3, batchSize: 2, failures: 1, want: []int{1}, wantErr: true, requests: 2, buffered: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newImportServer(t, test.failures)
+			metric := testMetric(t, 100)
+			experiment := &types.Experiment{K: 5}
+			for i := 0; i < test.samples; i++ {
+				metric.Add(float64(i), time.Now(), []string{"1"}, experiment)
+			}
+
+			p := &pusher{config: types.Push{URL: server.URL, BatchSize: test.batchSize, Retries: test.retries}, client: server.Client()}
+			err := p.push()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("push returned %v, want error %v", err, test.wantErr)
+			}
+
+			lines := []int{}
+			for _, body := range server.bodies {
+				lines = append(lines, strings.Count(body, "\n"))
+			}
+			if fmt.Sprint(lines) != fmt.Sprint(test.want) {
+				t.Errorf("received batches of %v lines, want %v", lines, test.want)
+			}
+			if server.requests != test.requests {
+				t.Errorf("received %d requests, want %d", server.requests, test.requests)
+			}
+			if buffered := len(metric.drain()); buffered != test.buffered {
+				t.Errorf("%d samples buffered, want %d", buffered, test.buffered)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package exporter
+
+import (
+	"prinkbenchmarking/src/types"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var startupTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "prink_startup_seconds",
+	Help: "Time from starting the SUT until the Prink job was running",
+}, types.ExperimentKeys())
+
+// ObserveStartup sets the startup time of the experiment's Prink job.
+func ObserveStartup(experiment *types.Experiment, d time.Duration) {
+	startupTime.WithLabelValues(experiment.ToLabels()...).Set(d.Seconds())
+}
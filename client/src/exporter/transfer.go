@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"fmt"
+	"prinkbenchmarking/src/types"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Latency and transfer metrics of the experiments, labelled with the experiment
+var (
+	latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "prink_latency_seconds",
+		Help: "End-to-end latency of the records in the measurement phase, from sending them to Prink until reading them back",
+		// classic buckets from 1ms to 32s for dashboards without native histogram support
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+		// native histogram with about 10% relative bucket width
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  160,
+		NativeHistogramMinResetDuration: time.Hour,
+	}, types.ExperimentKeys())
+	recordsSent     = newExperimentCounter("prink_records_sent_total", "Records sent to Prink")
+	recordsReceived = newExperimentCounter("prink_records_received_total", "Records read back from Prink")
+	bytesSent       = newExperimentCounter("prink_bytes_sent_total", "Bytes sent to Prink")
+	bytesReceived   = newExperimentCounter("prink_bytes_received_total", "Bytes read back from Prink")
+)
+
+func newExperimentCounter(name string, help string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, types.ExperimentKeys())
+}
+
+func transferMetrics() []prometheus.Collector {
+	return []prometheus.Collector{latency, recordsSent, recordsReceived, bytesSent, bytesReceived}
+}
+
+// Transfer holds the transfer metrics of an experiment, their labels are resolved once instead of per record.
+type Transfer struct {
+	recordsSent     prometheus.Counter
+	recordsReceived prometheus.Counter
+	bytesSent       prometheus.Counter
+	bytesReceived   prometheus.Counter
+	latency         prometheus.Observer
+}
+
+// NewTransfer returns the transfer metrics of the experiment.
+func NewTransfer(experiment *types.Experiment) (*Transfer, error) {
+	labels := experiment.ToLabels()
+	t := &Transfer{}
+	for _, c := range []struct {
+		vec    *prometheus.CounterVec
+		target *prometheus.Counter
+	}{
+		{recordsSent, &t.recordsSent},
+		{recordsReceived, &t.recordsReceived},
+		{bytesSent, &t.bytesSent},
+		{bytesReceived, &t.bytesReceived},
+	} {
+		counter, err := c.vec.GetMetricWithLabelValues(labels...)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve transfer metrics: %v", err)
+		}
+		*c.target = counter
+	}
+	observer, err := latency.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve latency metric: %v", err)
+	}
+	t.latency = observer
+	return t, nil
+}
+
+// RecordSent counts a record of the given size sent to Prink.
+func (t *Transfer) RecordSent(bytes int) {
+	t.recordsSent.Inc()
+	t.bytesSent.Add(float64(bytes))
+}
+
+// RecordReceived counts a record of the given size read back from Prink.
+func (t *Transfer) RecordReceived(bytes int) {
+	t.recordsReceived.Inc()
+	t.bytesReceived.Add(float64(bytes))
+}
+
+// ObserveLatency adds the end-to-end latency of a record in the measurement phase.
+func (t *Transfer) ObserveLatency(d time.Duration) {
+	t.latency.Observe(d.Seconds())
+}
@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"math"
+	"prinkbenchmarking/src/prink"
+	"prinkbenchmarking/src/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var vertexLabels = append([]string{"vertex"}, types.ExperimentKeys()...)
+
+func newVertexGauge(name string, help string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, vertexLabels)
+}
+
+// Vertex metrics of the Prink job, collected from the Flink REST API
+var (
+	vertexRecordsIn    = newVertexGauge("prink_vertex_records_in", "Records received by the vertex, summed over its subtasks")
+	vertexRecordsOut   = newVertexGauge("prink_vertex_records_out", "Records emitted by the vertex, summed over its subtasks")
+	vertexBackpressure = newVertexGauge("prink_vertex_backpressure_ratio", "Share of the time the subtasks of the vertex were back pressured")
+	vertexBusy         = newVertexGauge("prink_vertex_busy_ratio", "Share of the time the subtasks of the vertex were busy")
+	vertexIdle         = newVertexGauge("prink_vertex_idle_ratio", "Share of the time the subtasks of the vertex were idle")
+	vertexWatermarkLag = newVertexGauge("prink_vertex_watermark_lag_ms", "Event time of the newest record sent minus the lowest watermark of the vertex in milliseconds")
+)
+
+func vertexGauges() []prometheus.Collector {
+	return []prometheus.Collector{vertexRecordsIn, vertexRecordsOut, vertexBackpressure, vertexBusy, vertexIdle, vertexWatermarkLag}
+}
+
+// ExportVertexMetrics sets the vertex gauges of the experiment to the collected metrics.
+func ExportVertexMetrics(metrics []prink.VertexMetrics, experiment *types.Experiment) {
+	for _, m := range metrics {
+		labels := append([]string{m.Name}, experiment.ToLabels()...)
+		vertexRecordsIn.WithLabelValues(labels...).Set(m.RecordsIn)
+		vertexRecordsOut.WithLabelValues(labels...).Set(m.RecordsOut)
+		vertexBackpressure.WithLabelValues(labels...).Set(m.BackpressureRatio)
+		vertexBusy.WithLabelValues(labels...).Set(m.BusyRatio)
+		vertexIdle.WithLabelValues(labels...).Set(m.IdleRatio)
+		if !math.IsNaN(m.WatermarkLagMs) {
+			vertexWatermarkLag.WithLabelValues(labels...).Set(m.WatermarkLagMs)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package failure classifies the errors experiments fail with, so the retry policy can depend on the cause.
+package failure
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Class is the kind of failure of an experiment.
+type Class string
+
+const (
+	// ListenerTimeout: Prink did not connect to the client's sockets in time.
+	ListenerTimeout Class = "listener_timeout"
+	// ContainerExit: the jobmanager container exited with a non-zero exit code.
+	ContainerExit Class = "container_exit"
+	// ImagePull: the Prink image could not be pulled.
+	ImagePull Class = "image_pull"
+	// SocketWrite: the records could not be sent to Prink.
+	SocketWrite Class = "socket_write"
+	// PortConflict: no ports could be assigned to the experiment or one of them was already bound on the SUT host.
+	PortConflict Class = "port_conflict"
+	// JobFailed: the Flink job failed or was cancelled before it was running.
+	JobFailed Class = "job_failed"
+	// Timeout: the experiment exceeded its experiment_timeout.
+	Timeout Class = "timeout"
+	// Unknown failures have none of the other classes.
+	Unknown Class = "unknown"
+)
+
+// Classes returns all classes of failures.
+func Classes() []Class {
+	return []Class{ListenerTimeout, ContainerExit, ImagePull, SocketWrite, PortConflict, JobFailed, Timeout, Unknown}
+}
+
+// Error is an error with the class of the failure it caused.
+type Error struct {
+	Class Class
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Class, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns err classified as class, or nil if err is nil.
+func New(class Class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Class: class, Err: err}
+}
+
+// Errorf returns an error of the class with the formatted message.
+func Errorf(class Class, format string, args ...any) error {
+	return &Error{Class: class, Err: fmt.Errorf(format, args...)}
+}
+
+// ClassOf returns the class of the outermost classified error in err's chain, or Unknown if there is none.
+func ClassOf(err error) Class {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+	return Unknown
+}
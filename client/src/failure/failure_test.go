@@ -0,0 +1,48 @@
+package failure
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClassOf(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{name: "nil", err: nil, want: Unknown},
+		{name: "unclassified", err: cause, want: Unknown},
+		{name: "classified", err: New(SocketWrite, cause), want: SocketWrite},
+		{name: "formatted", err: Errorf(ImagePull, "could not pull %s: %v", "prink", cause), want: ImagePull},
+		{name: "wrapped", err: fmt.Errorf("SUT stopped: %w", New(ContainerExit, cause)), want: ContainerExit},
+		{name: "outermost class", err: New(Timeout, fmt.Errorf("cancelled: %w", New(SocketWrite, cause))), want: Timeout},
+		{name: "formatted without wrapping", err: fmt.Errorf("SUT stopped: %v", New(ContainerExit, cause)), want: Unknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ClassOf(test.err); got != test.want {
+				t.Errorf("ClassOf(%v) = %s, want %s", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if err := New(JobFailed, nil); err != nil {
+		t.Errorf("New with nil error returned %v", err)
+	}
+
+	cause := errors.New("job is FAILED")
+	err := New(JobFailed, cause)
+	if err.Error() != "job_failed: job is FAILED" {
+		t.Errorf("got message %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("classified error does not wrap its cause")
+	}
+}
@@ -0,0 +1,276 @@
+package fakesut
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"prinkbenchmarking/src/failure"
+	"prinkbenchmarking/src/prink"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	jobId          = "fa4e5bbd0c2f4f3c9ab1b6c0e1d2f3a4"
+	sourceVertexId = "bc764cd8ddf7a0cff126f51c16239658"
+	prinkVertexId  = "4150b807e25f98bebfeb73f2fab67d53"
+	sinkVertexId   = "ea632d67b7d595e5b851708ae9ad79d6"
+)
+
+// counters are the records processed by the fake job, reported as vertex metrics.
+type counters struct {
+	received atomic.Int64
+	emitted  atomic.Int64
+	// newest event time of the records received in milliseconds, math.MinInt64 before the first one
+	eventTime atomic.Int64
+}
+
+// restServer serves the parts of the Flink REST API the client queries.
+type restServer struct {
+	server   *http.Server
+	counters counters
+
+	mtx   sync.Mutex
+	state string
+	start time.Time
+	end   time.Time
+	// failure is the error the job failed with, reported as its exception history
+	failure error
+}
+
+// startRest serves the REST API on the port the experiment assigned to it.
+func startRest(port int) (*restServer, error) {
+	r := &restServer{state: "CREATED", start: time.Now()}
+	r.counters.eventTime.Store(math.MinInt64)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs/overview", r.handleOverview)
+	mux.HandleFunc("/jobs/", r.handleJob)
+	r.server = &http.Server{Handler: mux}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, failure.Errorf(failure.PortConflict, "could not start fake Flink REST API: %v", err)
+	}
+
+	go func() {
+		if err := r.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Fake Flink REST API stopped: %v", err)
+		}
+	}()
+
+	return r, nil
+}
+
+func (r *restServer) setState(state string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.state = state
+	if state != "RUNNING" {
+		r.end = time.Now()
+	}
+}
+
+func (r *restServer) run() {
+	r.setState("RUNNING")
+}
+
+func (r *restServer) finish() {
+	r.setState("FINISHED")
+}
+
+func (r *restServer) fail(err error) {
+	log.Printf("Fake SUT failed: %v", err)
+	r.mtx.Lock()
+	r.failure = err
+	r.mtx.Unlock()
+	r.setState("FAILED")
+}
+
+func (r *restServer) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r.server.Shutdown(ctx)
+}
+
+// times returns the state, start, end and duration of the job in milliseconds like Flink reports them.
+func (r *restServer) times() (string, int, int, int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	end := -1
+	duration := int(time.Since(r.start).Milliseconds())
+	if !r.end.IsZero() {
+		end = int(r.end.UnixMilli())
+		duration = int(r.end.Sub(r.start).Milliseconds())
+	}
+	return r.state, int(r.start.UnixMilli()), end, duration
+}
+
+func (r *restServer) handleOverview(w http.ResponseWriter, req *http.Request) {
+	state, start, end, duration := r.times()
+	writeJSON(w, map[string]any{
+		"jobs": []map[string]any{{
+			"jid":               jobId,
+			"name":              "Prink",
+			"start-time":        start,
+			"end-time":          end,
+			"duration":          duration,
+			"state":             state,
+			"last-modification": start,
+			"tasks":             map[string]int{"total": 3},
+		}},
+	})
+}
+
+func (r *restServer) handleJob(w http.ResponseWriter, req *http.Request) {
+	path := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/jobs/"), "/"), "/")
+	if path[0] != jobId {
+		http.NotFound(w, req)
+		return
+	}
+
+	switch {
+	case len(path) == 1:
+		writeJSON(w, r.jobDetails())
+	case len(path) == 2 && path[1] == "exceptions":
+		writeJSON(w, r.exceptions())
+	case len(path) == 4 && path[1] == "vertices" && path[3] == "flamegraph":
+		writeJSON(w, prink.FlamegraphResponse{
+			EndTimestamp: int(time.Now().UnixMilli()),
+			Data:         flamegraph(path[2]),
+		})
+	case len(path) == 4 && path[1] == "vertices" && path[3] == "watermarks":
+		writeJSON(w, []map[string]string{{"id": "0.currentInputWatermark", "value": strconv.FormatInt(r.watermark(), 10)}})
+	case len(path) == 5 && path[1] == "vertices" && path[3] == "subtasks" && path[4] == "metrics":
+		writeJSON(w, r.vertexMetrics(path[2], strings.Split(req.URL.Query().Get("get"), ",")))
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (r *restServer) exceptions() prink.JobExceptions {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	exceptions := prink.JobExceptions{}
+	if r.failure != nil {
+		exceptions.RootException = r.failure.Error()
+		exceptions.ExceptionHistory.Entries = append(exceptions.ExceptionHistory.Entries, prink.JobException{
+			ExceptionName: "java.io.IOException",
+			Stacktrace:    "java.io.IOException: " + r.failure.Error(),
+			Timestamp:     r.end.UnixMilli(),
+			TaskName:      "Source: Socket Stream",
+		})
+	}
+	return exceptions
+}
+
+func (r *restServer) jobDetails() prink.JobDetails {
+	state, start, end, duration := r.times()
+	vertex := func(id string, name string) prink.Vertex {
+		return prink.Vertex{
+			ID:             id,
+			Name:           name,
+			MaxParallelism: 128,
+			Parallelism:    1,
+			Status:         state,
+			StartTime:      start,
+			EndTime:        end,
+			Duration:       duration,
+		}
+	}
+
+	return prink.JobDetails{
+		JID:            jobId,
+		Name:           "Prink",
+		State:          state,
+		JobType:        "STREAMING",
+		StartTime:      start,
+		EndTime:        end,
+		Duration:       duration,
+		MaxParallelism: -1,
+		Now:            int(time.Now().UnixMilli()),
+		Vertices: []prink.Vertex{
+			vertex(sourceVertexId, "Source: Socket Stream"),
+			vertex(prinkVertexId, "keyed-castle-generalization"),
+			vertex(sinkVertexId, "Sink: Socket Sink"),
+		},
+	}
+}
+
+// vertexMetrics returns the requested subtask metrics of the vertex, aggregated over its single subtask.
+func (r *restServer) vertexMetrics(vertexId string, names []string) []map[string]any {
+	state, _, _, _ := r.times()
+	received := float64(r.counters.received.Load())
+	emitted := float64(r.counters.emitted.Load())
+
+	values := map[string]float64{"backPressuredTimeMsPerSecond": 0, "busyTimeMsPerSecond": 0, "idleTimeMsPerSecond": 1000}
+	switch vertexId {
+	case sourceVertexId:
+		values["numRecordsIn"], values["numRecordsOut"] = received, received
+	case prinkVertexId:
+		values["numRecordsIn"], values["numRecordsOut"] = received, emitted
+		if state == "RUNNING" {
+			values["busyTimeMsPerSecond"], values["idleTimeMsPerSecond"] = 100, 900
+		}
+	default:
+		values["numRecordsIn"], values["numRecordsOut"] = emitted, 0
+	}
+
+	metrics := []map[string]any{}
+	for _, name := range names {
+		if value, ok := values[name]; ok {
+			metrics = append(metrics, map[string]any{"id": name, "min": value, "max": value, "avg": value, "sum": value, "skew": 0})
+		}
+	}
+	return metrics
+}
+
+// watermark returns a watermark shortly behind the newest event time received, and Long.MIN_VALUE before.
+func (r *restServer) watermark() int64 {
+	eventTime := r.counters.eventTime.Load()
+	if eventTime == math.MinInt64 {
+		return math.MinInt64
+	}
+	return eventTime - 200
+}
+
+// flamegraph returns a small fixed call tree for the vertex.
+func flamegraph(vertexId string) prink.Flamegraph {
+	if vertexId != prinkVertexId {
+		return prink.Flamegraph{Name: "root", Children: []prink.Flamegraph{}}
+	}
+
+	leaf := func(name string, value int) prink.Flamegraph {
+		return prink.Flamegraph{Name: name, Value: value, Children: []prink.Flamegraph{}}
+	}
+	return prink.Flamegraph{
+		Name:  "root",
+		Value: 100,
+		Children: []prink.Flamegraph{{
+			Name:  "org.apache.flink.streaming.runtime.tasks.StreamTask.invoke",
+			Value: 100,
+			Children: []prink.Flamegraph{
+				leaf("prink.CastleFunction.processElement", 70),
+				leaf("prink.generalizers.AggregationFloatGeneralizer.generalize", 20),
+				leaf("org.apache.flink.runtime.io.network.api.writer.RecordWriter.emit", 10),
+			},
+		}},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Could not write fake Flink REST response: %v", err)
+	}
+}
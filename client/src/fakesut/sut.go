@@ -0,0 +1,313 @@
+// Package fakesut is a stand-in for Prink which runs in the client's process.
+// It connects to the client's sockets like the Prink job does, groups the records into clusters
+// of at least k individuals, generalizes their quasi-identifiers and returns them with the fields Prink appends.
+// A minimal Flink REST API is served, so the harness can be run end to end without Docker.
+package fakesut
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"prinkbenchmarking/src/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// connectTimeout is how long the fake SUT tries to reach the client's sockets.
+const connectTimeout = 5 * time.Minute
+
+// Run runs the fake SUT for the experiment until the client stopped sending records or the context is cancelled.
+// started is closed once the REST API is served.
+func Run(ctx context.Context, experiment *types.Experiment, config types.Config, started chan<- bool) error {
+	rest, err := startRest(experiment.RestPort)
+	if err != nil {
+		return err
+	}
+	defer rest.stop()
+	close(started)
+
+	// like Flink, the job is running before its sources and sinks connect
+	rest.run()
+
+	input, err := dial(ctx, experiment.LocalHost, experiment.SutPortWrite)
+	if err != nil {
+		rest.fail(err)
+		return err
+	}
+	defer input.Close()
+
+	output, err := dial(ctx, experiment.LocalHost, experiment.SutPortRead)
+	if err != nil {
+		rest.fail(err)
+		return err
+	}
+	defer output.Close()
+
+	// unblock the anonymizer if the experiment is cancelled
+	stop := context.AfterFunc(ctx, func() {
+		input.Close()
+		output.Close()
+	})
+	defer stop()
+
+	if err := newAnonymizer(experiment, config.Schema, &rest.counters).run(input, output); err != nil {
+		rest.fail(err)
+		return err
+	}
+	rest.finish()
+
+	return nil
+}
+
+// dial connects to the client, which might not listen yet.
+func dial(ctx context.Context, host string, port int) (net.Conn, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	deadline := time.Now().Add(connectTimeout)
+	for {
+		conn, err := net.Dial("tcp", address)
+		if err == nil {
+			return conn, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("could not connect to %s: %v", address, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("could not connect to %s: %v", address, context.Cause(ctx))
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+type fakeRecord struct {
+	fields   []string
+	received time.Time
+}
+
+// anonymizer clusters records in arrival order. A cluster is complete once it contains k individuals
+// and l sensitive values; all of its records get the same generalized quasi-identifiers.
+// A complete cluster is only released once the next one is complete, so the records left at the end,
+// which are not k-anonymous by themselves, can be merged into it.
+type anonymizer struct {
+	k, l int
+
+	schema           types.Schema
+	quasiIdentifiers []int
+	id               int
+	sensitive        int
+	timestamp        int
+
+	// value ranges of the numeric quasi-identifiers seen so far, to compute the info loss
+	minimum map[int]float64
+	maximum map[int]float64
+
+	cluster []fakeRecord
+	// distinct individuals and sensitive values of the cluster
+	ids        map[string]struct{}
+	sensitives map[string]struct{}
+	// complete cluster which is not released yet
+	pending []fakeRecord
+
+	counters *counters
+}
+
+func newAnonymizer(experiment *types.Experiment, schema types.Schema, counters *counters) *anonymizer {
+	a := &anonymizer{
+		counters:   counters,
+		k:          experiment.K,
+		l:          experiment.L,
+		schema:     schema,
+		id:         -1,
+		sensitive:  -1,
+		timestamp:  -1,
+		minimum:    map[int]float64{},
+		maximum:    map[int]float64{},
+		ids:        map[string]struct{}{},
+		sensitives: map[string]struct{}{},
+	}
+	for i, column := range schema.Columns {
+		switch column.Role {
+		case types.RoleQuasiIdentifier:
+			a.quasiIdentifiers = append(a.quasiIdentifiers, i)
+		case types.RoleID:
+			a.id = i
+		case types.RoleSensitive:
+			a.sensitive = i
+		case types.RoleTimestamp:
+			a.timestamp = i
+		}
+	}
+	return a
+}
+
+func (a *anonymizer) run(input net.Conn, output net.Conn) error {
+	writer := bufio.NewWriter(output)
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		// dataset fields followed by m_id and t_s
+		fields := strings.Split(scanner.Text(), ";")
+		if len(fields) != len(a.schema.Columns)+2 {
+			return fmt.Errorf("received record with %d fields, expected %d", len(fields), len(a.schema.Columns)+2)
+		}
+
+		a.counters.received.Add(1)
+		a.observe(fields)
+		a.add(fakeRecord{fields: fields, received: time.Now()})
+		if a.complete() {
+			if err := a.release(writer, a.pending); err != nil {
+				return err
+			}
+			a.pending = a.cluster
+			a.cluster = nil
+			clear(a.ids)
+			clear(a.sensitives)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// the remaining records are merged into the last complete cluster, like Prink suppresses nothing at the end
+	if err := a.release(writer, append(a.pending, a.cluster...)); err != nil {
+		return err
+	}
+
+	log.Printf("Fake SUT processed all records")
+	return writer.Flush()
+}
+
+func (a *anonymizer) observe(fields []string) {
+	for _, column := range a.quasiIdentifiers {
+		value, err := strconv.ParseFloat(fields[column], 64)
+		if err != nil {
+			continue
+		}
+		if minimum, ok := a.minimum[column]; !ok || value < minimum {
+			a.minimum[column] = value
+		}
+		if maximum, ok := a.maximum[column]; !ok || value > maximum {
+			a.maximum[column] = value
+		}
+	}
+
+	if a.timestamp >= 0 {
+		if ts, err := time.Parse(a.schema.Layout(), fields[a.timestamp]); err == nil && ts.UnixMilli() > a.counters.eventTime.Load() {
+			a.counters.eventTime.Store(ts.UnixMilli())
+		}
+	}
+}
+
+// add adds the record to the cluster.
+func (a *anonymizer) add(record fakeRecord) {
+	a.cluster = append(a.cluster, record)
+	if a.id >= 0 {
+		a.ids[record.fields[a.id]] = struct{}{}
+	}
+	if a.sensitive >= 0 {
+		a.sensitives[record.fields[a.sensitive]] = struct{}{}
+	}
+}
+
+// complete reports whether the cluster contains k individuals and l sensitive values.
+// Without an id column every record is an individual.
+func (a *anonymizer) complete() bool {
+	individuals := len(a.ids)
+	if a.id < 0 {
+		individuals = len(a.cluster)
+	}
+	sensitives := len(a.sensitives)
+	if a.sensitive < 0 {
+		sensitives = len(a.cluster)
+	}
+	return individuals >= a.k && (a.l <= 0 || sensitives >= a.l)
+}
+
+// release generalizes the quasi-identifiers of the cluster and writes its records.
+func (a *anonymizer) release(writer *bufio.Writer, cluster []fakeRecord) error {
+	if len(cluster) == 0 {
+		return nil
+	}
+	released := time.Now()
+	generalized := map[int]string{}
+	infoLoss := 0.0
+
+	for _, column := range a.quasiIdentifiers {
+		var value string
+		var loss float64
+		if a.schema.Columns[column].Type == "int" || a.schema.Columns[column].Type == "float" {
+			value, loss = a.generalizeNumeric(column, cluster)
+		} else {
+			value, loss = a.generalizeCategorical(column, cluster)
+		}
+		generalized[column] = value
+		infoLoss += loss
+	}
+	if len(a.quasiIdentifiers) > 0 {
+		infoLoss /= float64(len(a.quasiIdentifiers))
+	}
+
+	for _, record := range cluster {
+		fields := append([]string{}, record.fields...)
+		for column, value := range generalized {
+			fields[column] = value
+		}
+
+		// Prink appends t_bs, t_bse, t_d, t_de and info_loss
+		line := strings.Join(fields, ";") + fmt.Sprintf(";%d;%d;%d;%d;%f\n",
+			record.received.UnixMilli(), released.UnixMilli(), released.UnixMilli(), time.Now().UnixMilli(), infoLoss)
+		if _, err := writer.WriteString(line); err != nil {
+			return fmt.Errorf("could not write to client: %v", err)
+		}
+		a.counters.emitted.Add(1)
+	}
+
+	return writer.Flush()
+}
+
+// generalizeNumeric returns the range of the column in the cluster and its width relative to all values seen.
+func (a *anonymizer) generalizeNumeric(column int, cluster []fakeRecord) (string, float64) {
+	minimum, maximum := math.Inf(1), math.Inf(-1)
+	for _, record := range cluster {
+		value, err := strconv.ParseFloat(record.fields[column], 64)
+		if err != nil {
+			continue
+		}
+		minimum = math.Min(minimum, value)
+		maximum = math.Max(maximum, value)
+	}
+	if math.IsInf(minimum, 0) {
+		return "", 1
+	}
+
+	loss := 0.0
+	if width := a.maximum[column] - a.minimum[column]; width > 0 {
+		loss = (maximum - minimum) / width
+	}
+	return fmt.Sprintf("(%s,%s)", strconv.FormatFloat(minimum, 'f', -1, 64), strconv.FormatFloat(maximum, 'f', -1, 64)), loss
+}
+
+// generalizeCategorical returns the set of values of the column in the cluster,
+// or the value itself if all records share it.
+func (a *anonymizer) generalizeCategorical(column int, cluster []fakeRecord) (string, float64) {
+	values := map[string]struct{}{}
+	for _, record := range cluster {
+		values[record.fields[column]] = struct{}{}
+	}
+	if len(values) == 1 {
+		return cluster[0].fields[column], 0
+	}
+
+	sorted := make([]string, 0, len(values))
+	for value := range values {
+		sorted = append(sorted, value)
+	}
+	sort.Strings(sorted)
+	return "(" + strings.Join(sorted, ",") + ")", float64(len(values)-1) / float64(len(values))
+}
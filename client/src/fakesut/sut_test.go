@@ -0,0 +1,118 @@
+package fakesut
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"prinkbenchmarking/src/types"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAnonymizer(t *testing.T) {
+	schema := types.Schema{Columns: []types.Column{
+		{Name: "id", Type: "int", Role: types.RoleID},
+		{Name: "age", Type: "int", Role: types.RoleQuasiIdentifier},
+		{Name: "use", Type: "string", Role: types.RoleQuasiIdentifier},
+		{Name: "disease", Type: "string", Role: types.RoleSensitive},
+		{Name: "timestamp", Type: "time", Role: types.RoleTimestamp},
+	}}
+
+	tests := []struct {
+		name string
+		k, l int
+		// id, age, use and disease of the records sent
+		records []string
+		// m_id, age and use of the records released, in the order they are released
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "clusters of k individuals",
+			k:       2,
+			records: []string{"1;10;office;flu", "2;20;office;flu", "3;30;office;cold", "4;40;school;cold"},
+			want:    []string{"0;(10,20);office", "1;(10,20);office", "2;(30,40);(office,school)", "3;(30,40);(office,school)"},
+		},
+		{
+			name:    "remaining records are merged into the last cluster",
+			k:       2,
+			records: []string{"1;10;office;flu", "2;20;office;flu", "3;30;office;flu", "4;40;office;flu", "5;50;office;flu"},
+			want:    []string{"0;(10,20);office", "1;(10,20);office", "2;(30,50);office", "3;(30,50);office", "4;(30,50);office"},
+		},
+		{
+			name:    "records of the same individual",
+			k:       2,
+			records: []string{"1;10;office;flu", "1;20;office;flu", "2;30;office;flu"},
+			want:    []string{"0;(10,30);office", "1;(10,30);office", "2;(10,30);office"},
+		},
+		{
+			name:    "l sensitive values",
+			k:       2,
+			l:       2,
+			records: []string{"1;10;office;flu", "2;20;office;flu", "3;30;office;cold", "4;40;office;cold", "5;50;office;flu"},
+			// two individuals with the same sensitive value are not enough
+			want: []string{"0;(10,30);office", "1;(10,30);office", "2;(10,30);office", "3;(40,50);office", "4;(40,50);office"},
+		},
+		{
+			name:    "fewer than k individuals",
+			k:       5,
+			records: []string{"1;10;office;flu", "2;20;school;flu"},
+			want:    []string{"0;(10,20);(office,school)", "1;(10,20);(office,school)"},
+		},
+		{
+			name:    "record with missing fields",
+			k:       2,
+			records: []string{"1;10;office"},
+			wantErr: "expected 7",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			experiment := types.DefaultExperiment()
+			experiment.K, experiment.L = test.k, test.l
+			a := newAnonymizer(&experiment, schema, &counters{})
+
+			input, client := net.Pipe()
+			output, results := net.Pipe()
+			go func() {
+				defer client.Close()
+				for i, record := range test.records {
+					fmt.Fprintf(client, "%s;2016-01-01 00:00:%02d;%d;0\n", record, i, i)
+				}
+			}()
+			released := make(chan []string)
+			go func() {
+				lines := []string{}
+				scanner := bufio.NewScanner(results)
+				for scanner.Scan() {
+					fields := strings.Split(scanner.Text(), ";")
+					lines = append(lines, strings.Join([]string{fields[len(schema.Columns)], fields[1], fields[2]}, ";"))
+				}
+				released <- lines
+			}()
+
+			err := a.run(input, output)
+			input.Close()
+			output.Close()
+			got := <-released
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("released %v, want %v", got, test.want)
+			}
+			if emitted := a.counters.emitted.Load(); emitted != int64(len(test.records)) {
+				t.Errorf("emitted %d records, want %d", emitted, len(test.records))
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package ports
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Allocator assigns ports of a range to the experiments running at the same time,
+// so they can share the client and a Docker host without binding the same ports.
+// The ports of all hosts are taken from one pool, as the client and the SUT may be the same machine.
+type Allocator struct {
+	mutex   sync.Mutex
+	start   int
+	end     int
+	used    map[int]bool
+	blocked map[int]bool
+}
+
+// NewAllocator returns an allocator of the ports from start to end, both included.
+func NewAllocator(start int, end int) *Allocator {
+	return &Allocator{start: start, end: end, used: map[int]bool{}, blocked: map[int]bool{}}
+}
+
+// Allocate reserves n consecutive ports and returns the first one.
+// If probe is set, ports which are already bound on this machine, e.g. by other clients, are skipped.
+// Ports bound on another host, like the SUT's Docker host, cannot be probed; see Block.
+func (a *Allocator) Allocate(n int, probe bool) (int, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for first := a.start; first+n-1 <= a.end; first++ {
+		free := true
+		for port := first; port < first+n; port++ {
+			if a.used[port] || a.blocked[port] || (probe && !available(port)) {
+				// continue after the port in use
+				first = port
+				free = false
+				break
+			}
+		}
+		if !free {
+			continue
+		}
+
+		for port := first; port < first+n; port++ {
+			a.used[port] = true
+		}
+		return first, nil
+	}
+	return 0, fmt.Errorf("no %d consecutive free ports in %d-%d", n, a.start, a.end)
+}
+
+// Release frees the n ports starting at first.
+func (a *Allocator) Release(first int, n int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for port := first; port < first+n; port++ {
+		delete(a.used, port)
+	}
+}
+
+// Block excludes the n ports starting at first from all further allocations,
+// e.g. because one of them turned out to be bound on the SUT host.
+func (a *Allocator) Block(first int, n int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for port := first; port < first+n; port++ {
+		a.blocked[port] = true
+	}
+}
+
+// available reports whether the port can be bound on this machine.
+func available(port int) bool {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
@@ -0,0 +1,113 @@
+package ports
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+// freeRange returns the first port of n consecutive ports which are free on this machine.
+func freeRange(t *testing.T, n int) int {
+	for first := 40000; first < 60000; first += n {
+		free := true
+		for port := first; port < first+n; port++ {
+			if !available(port) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return first
+		}
+	}
+	t.Skip("no free ports")
+	return 0
+}
+
+func TestAllocator(t *testing.T) {
+	start := freeRange(t, 10)
+
+	type allocation struct {
+		n       int
+		probe   bool
+		release bool
+		block   bool
+		// offset of the first port from start, -1 if the allocation fails
+		want int
+	}
+
+	tests := []struct {
+		name        string
+		size        int
+		bound       []int
+		allocations []allocation
+	}{
+		{
+			name:        "consecutive allocations",
+			size:        10,
+			allocations: []allocation{{n: 2, want: 0}, {n: 3, want: 2}, {n: 1, want: 5}},
+		},
+		{
+			name:        "range exhausted",
+			size:        4,
+			allocations: []allocation{{n: 3, want: 0}, {n: 2, want: -1}, {n: 1, want: 3}},
+		},
+		{
+			name:        "released ports are allocated again",
+			size:        4,
+			allocations: []allocation{{n: 4, want: 0, release: true}, {n: 2, want: 0}},
+		},
+		{
+			name:        "blocked ports are never allocated again",
+			size:        6,
+			allocations: []allocation{{n: 2, want: 0, block: true, release: true}, {n: 2, want: 2}, {n: 2, want: 4}, {n: 1, want: -1}},
+		},
+		{
+			name:        "probed ports skip ports bound on this machine",
+			size:        6,
+			bound:       []int{1},
+			allocations: []allocation{{n: 2, probe: true, want: 2}},
+		},
+		{
+			name:        "ports are not probed for other hosts",
+			size:        6,
+			bound:       []int{1},
+			allocations: []allocation{{n: 2, want: 0}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, offset := range test.bound {
+				listener, err := net.Listen("tcp", fmt.Sprintf(":%d", start+offset))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer listener.Close()
+			}
+
+			allocator := NewAllocator(start, start+test.size-1)
+			for i, allocation := range test.allocations {
+				first, err := allocator.Allocate(allocation.n, allocation.probe)
+				if allocation.want < 0 {
+					if err == nil {
+						t.Errorf("allocation %d got port %d, want an error", i, first)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("allocation %d failed: %v", i, err)
+				}
+				if first != start+allocation.want {
+					t.Errorf("allocation %d got port %d, want %d", i, first, start+allocation.want)
+				}
+				if allocation.block {
+					allocator.Block(first, allocation.n)
+				}
+				if allocation.release {
+					allocator.Release(first, allocation.n)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package prink
+
+import (
+	"context"
+
+	"prinkbenchmarking/src/types"
+
+	"github.com/docker/docker/client"
+)
+
+// SutInfo describes the Docker host of a SUT and the Prink image it runs.
+type SutInfo struct {
+	DockerHost      string `json:"docker_host"`
+	ServerVersion   string `json:"server_version"`
+	OperatingSystem string `json:"operating_system"`
+	KernelVersion   string `json:"kernel_version"`
+	Architecture    string `json:"architecture"`
+	CPUs            int    `json:"cpus"`
+	MemoryBytes     int64  `json:"memory_bytes"`
+
+	Image        string   `json:"image"`
+	ImageID      string   `json:"image_id"`
+	ImageDigests []string `json:"image_digests"`
+}
+
+// DescribeSut returns the Docker host of the experiment's SUT and the resolved Prink image.
+// The image must have been pulled already.
+func DescribeSut(experiment *types.Experiment, config types.Config) (*SutInfo, error) {
+	ctx := context.Background()
+
+	dockerHost, err := getDockerHost(experiment, config)
+	if err != nil {
+		return nil, err
+	}
+
+	cli, err := client.NewClientWithOpts(
+		client.FromEnv,
+		client.WithAPIVersionNegotiation(),
+		client.WithHost(dockerHost),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sut := &SutInfo{
+		DockerHost:      dockerHost,
+		ServerVersion:   info.ServerVersion,
+		OperatingSystem: info.OperatingSystem,
+		KernelVersion:   info.KernelVersion,
+		Architecture:    info.Architecture,
+		CPUs:            info.NCPU,
+		MemoryBytes:     info.MemTotal,
+		Image:           config.PrinkDockerImage,
+	}
+
+	image, _, err := cli.ImageInspectWithRaw(ctx, config.PrinkDockerImage)
+	if err != nil {
+		return sut, err
+	}
+	sut.ImageID = image.ID
+	sut.ImageDigests = image.RepoDigests
+
+	return sut, nil
+}
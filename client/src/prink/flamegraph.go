@@ -0,0 +1,63 @@
+package prink
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Merge adds the samples of the other flamegraph to this one, merging frames with the same name at the same depth.
+func (f *Flamegraph) Merge(other Flamegraph) {
+	f.Value += other.Value
+	for _, child := range other.Children {
+		merged := false
+		for i := range f.Children {
+			if f.Children[i].Name == child.Name {
+				f.Children[i].Merge(child)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			copied := Flamegraph{Name: child.Name}
+			copied.Merge(child)
+			f.Children = append(f.Children, copied)
+		}
+	}
+}
+
+// WriteFolded writes the flamegraph in the folded stacks format of Brendan Gregg's flamegraph tools:
+// one line per stack with its frames separated by semicolons and the number of samples ending in it.
+// The root frame is omitted.
+func (f *Flamegraph) WriteFolded(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	for _, child := range f.Children {
+		if err := child.writeFolded(writer, nil); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func (f *Flamegraph) writeFolded(w *bufio.Writer, stack []string) error {
+	// frame names must not contain the separators of the format
+	stack = append(stack, strings.NewReplacer(";", ":", " ", "_").Replace(f.Name))
+
+	self := f.Value
+	for _, child := range f.Children {
+		self -= child.Value
+	}
+	if self > 0 {
+		if _, err := fmt.Fprintf(w, "%s %d\n", strings.Join(stack, ";"), self); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range f.Children {
+		if err := child.writeFolded(w, stack); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package prink
+
+import (
+	"strings"
+	"testing"
+)
+
+// frame returns a flamegraph frame with the given samples and children.
+func frame(name string, value int, children ...Flamegraph) Flamegraph {
+	return Flamegraph{Name: name, Value: value, Children: children}
+}
+
+func TestFlamegraph(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []Flamegraph
+		// the merged flamegraph in the folded stacks format
+		want string
+	}{
+		{
+			name:    "single sample",
+			samples: []Flamegraph{frame("root", 10, frame("main", 10, frame("work", 6)))},
+			want:    "main 4\nmain;work 6\n",
+		},
+		{
+			name: "same frames are added up",
+			samples: []Flamegraph{
+				frame("root", 10, frame("main", 10, frame("work", 6))),
+				frame("root", 5, frame("main", 5, frame("work", 5))),
+			},
+			want: "main 4\nmain;work 11\n",
+		},
+		{
+			name: "new frames are appended",
+			samples: []Flamegraph{
+				frame("root", 10, frame("main", 10, frame("work", 10))),
+				frame("root", 4, frame("main", 2, frame("sleep", 2)), frame("gc", 2)),
+			},
+			want: "main;work 10\nmain;sleep 2\ngc 2\n",
+		},
+		{
+			name:    "frames are merged by their depth",
+			samples: []Flamegraph{frame("root", 2, frame("work", 1), frame("main", 1, frame("work", 1)))},
+			want:    "work 1\nmain;work 1\n",
+		},
+		{
+			name:    "separators in frame names",
+			samples: []Flamegraph{frame("root", 1, frame("java.lang.Thread.run; at line 12", 1))},
+			want:    "java.lang.Thread.run:_at_line_12 1\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := Flamegraph{Name: "root"}
+			for _, sample := range test.samples {
+				merged.Merge(sample)
+			}
+
+			var folded strings.Builder
+			if err := merged.WriteFolded(&folded); err != nil {
+				t.Fatal(err)
+			}
+			if folded.String() != test.want {
+				t.Errorf("got folded stacks\n%s\nwant\n%s", folded.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestMergeDoesNotShareChildren(t *testing.T) {
+	sample := frame("root", 1, frame("main", 1))
+	merged := Flamegraph{Name: "root"}
+	merged.Merge(sample)
+	merged.Merge(sample)
+
+	if sample.Children[0].Value != 1 {
+		t.Errorf("merging changed the sample to %d samples", sample.Children[0].Value)
+	}
+	if merged.Children[0].Value != 2 {
+		t.Errorf("merged %d samples, want 2", merged.Children[0].Value)
+	}
+}
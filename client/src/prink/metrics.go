@@ -0,0 +1,103 @@
+package prink
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"prinkbenchmarking/src/types"
+	"strconv"
+	"strings"
+)
+
+// vertexMetricNames are the subtask metrics aggregated per vertex
+var vertexMetricNames = []string{
+	"numRecordsIn",
+	"numRecordsOut",
+	"backPressuredTimeMsPerSecond",
+	"busyTimeMsPerSecond",
+	"idleTimeMsPerSecond",
+}
+
+// VertexMetrics are the metrics of a vertex of the Prink job at one point in time.
+type VertexMetrics struct {
+	ID   string
+	Name string
+	// records received and emitted, summed over the subtasks
+	RecordsIn  float64
+	RecordsOut float64
+	// share of the time the subtasks were back pressured, busy or idle, averaged over the subtasks
+	BackpressureRatio float64
+	BusyRatio         float64
+	IdleRatio         float64
+	// difference between the event time of the newest record sent and the lowest watermark of the subtasks
+	// in milliseconds, NaN as long as the vertex has no watermark or no record was sent
+	WatermarkLagMs float64
+}
+
+type aggregatedMetric struct {
+	ID  string  `json:"id"`
+	Sum float64 `json:"sum"`
+	Avg float64 `json:"avg"`
+}
+
+type watermark struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+// GetVertexMetrics returns the metrics of all vertices of the running Prink job.
+// eventTime is the event time of the newest record sent in milliseconds, math.MinInt64 if none was sent.
+func GetVertexMetrics(experiment *types.Experiment, config types.Config, eventTime int64) ([]VertexMetrics, error) {
+	jobDetails, err := runningJob(experiment)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := []VertexMetrics{}
+	for _, vertex := range jobDetails.Vertices {
+		vertexURL := restURL(experiment) + "/jobs/" + jobDetails.JID + "/vertices/" + vertex.ID
+
+		aggregated := []aggregatedMetric{}
+		query := url.Values{"get": {strings.Join(vertexMetricNames, ",")}, "agg": {"sum,avg"}}
+		if err := getJSON(vertexURL+"/subtasks/metrics?"+query.Encode(), &aggregated); err != nil {
+			return nil, fmt.Errorf("could not get metrics of vertex %s: %v", vertex.Name, err)
+		}
+
+		m := VertexMetrics{ID: vertex.ID, Name: vertex.Name, WatermarkLagMs: math.NaN()}
+		for _, metric := range aggregated {
+			switch metric.ID {
+			case "numRecordsIn":
+				m.RecordsIn = metric.Sum
+			case "numRecordsOut":
+				m.RecordsOut = metric.Sum
+			case "backPressuredTimeMsPerSecond":
+				m.BackpressureRatio = metric.Avg / 1000
+			case "busyTimeMsPerSecond":
+				m.BusyRatio = metric.Avg / 1000
+			case "idleTimeMsPerSecond":
+				m.IdleRatio = metric.Avg / 1000
+			}
+		}
+
+		watermarks := []watermark{}
+		if err := getJSON(vertexURL+"/watermarks", &watermarks); err != nil {
+			return nil, fmt.Errorf("could not get watermarks of vertex %s: %v", vertex.Name, err)
+		}
+		lowest := int64(math.MaxInt64)
+		for _, w := range watermarks {
+			value, err := strconv.ParseInt(w.Value, 10, 64)
+			if err == nil && value < lowest {
+				lowest = value
+			}
+		}
+		// Flink reports Long.MIN_VALUE until the first watermark arrived
+		// the watermarks follow the event time of the dataset, not the wall clock
+		if lowest != math.MaxInt64 && lowest != math.MinInt64 && eventTime != math.MinInt64 {
+			m.WatermarkLagMs = float64(eventTime - lowest)
+		}
+
+		metrics = append(metrics, m)
+	}
+
+	return metrics, nil
+}
@@ -7,12 +7,14 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"prinkbenchmarking/src/failure"
 	"prinkbenchmarking/src/types"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
@@ -36,7 +38,43 @@ func getDockerHost(experiment *types.Experiment, config types.Config) (string, e
 	return writer.String(), nil
 }
 
-func CleanupPrink(dockerHost string) error {
+// Labels of the containers and networks created by StartPrink
+const (
+	LabelCampaign   = "prinkbenchmarking.campaign"
+	LabelExperiment = "prinkbenchmarking.experiment"
+)
+
+// CleanupFilter selects the containers and networks removed by CleanupPrink.
+// Only resources created by StartPrink are removed; an empty field matches all values.
+type CleanupFilter struct {
+	Campaign   string
+	Experiment string
+}
+
+func (f CleanupFilter) args() filters.Args {
+	args := filters.NewArgs(filters.Arg("label", LabelCampaign))
+	if f.Campaign != "" {
+		args.Add("label", LabelCampaign+"="+f.Campaign)
+	}
+	if f.Experiment != "" {
+		args.Add("label", LabelExperiment+"="+f.Experiment)
+	}
+	return args
+}
+
+func labels(experiment *types.Experiment, config types.Config) map[string]string {
+	return map[string]string{
+		LabelCampaign:   config.CampaignID,
+		LabelExperiment: experiment.ToFileName(),
+	}
+}
+
+// GetDockerHost returns the Docker host of the SUT address.
+func GetDockerHost(address string, config types.Config) (string, error) {
+	return getDockerHost(&types.Experiment{SutHost: address}, config)
+}
+
+func CleanupPrink(dockerHost string, filter CleanupFilter) error {
 	ctx := context.Background()
 
 	cli, err := client.NewClientWithOpts(
@@ -49,29 +87,44 @@ func CleanupPrink(dockerHost string) error {
 	}
 	defer cli.Close()
 
-	containers, err := cli.ContainerList(ctx, container.ListOptions{})
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true, Filters: filter.args()})
 	if err != nil {
 		return err
 	}
 
 	for _, ctn := range containers {
-		log.Printf("Removing container %s", ctn.ID)
+		log.Printf("Removing container %s of %s", ctn.ID, ctn.Labels[LabelExperiment])
 		if err := cli.ContainerRemove(ctx, ctn.ID, container.RemoveOptions{Force: true}); err != nil {
 			log.Printf("Could not remove container %s: %v", ctn.ID, err)
 		}
 	}
+
+	networks, err := cli.NetworkList(ctx, network.ListOptions{Filters: filter.args()})
+	if err != nil {
+		return err
+	}
+
+	for _, net := range networks {
+		log.Printf("Removing network %s", net.Name)
+		if err := cli.NetworkRemove(ctx, net.ID); err != nil {
+			log.Printf("Could not remove network %s: %v", net.Name, err)
+		}
+	}
 	return nil
 }
 
-func StartPrink(experiment *types.Experiment, config types.Config) error {
-	ctx := context.Background()
+// StartPrink runs the Flink cluster of the experiment until its job is done or the context is cancelled.
+// The logs are collected and the containers removed in both cases. started is closed once the containers are started.
+func StartPrink(stop context.Context, experiment *types.Experiment, config types.Config, started chan<- bool) error {
+	ctx := context.WithoutCancel(stop)
 
 	dockerHost, err := getDockerHost(experiment, config)
 	if err != nil {
 		return err
 	}
 
-	CleanupPrink(dockerHost)
+	// remove leftovers of an earlier try of this experiment
+	CleanupPrink(dockerHost, CleanupFilter{Campaign: config.CampaignID, Experiment: experiment.ToFileName()})
 
 	cli, err := client.NewClientWithOpts(
 		client.FromEnv,
@@ -85,11 +138,11 @@ func StartPrink(experiment *types.Experiment, config types.Config) error {
 
 	// reader, err := cli.ImagePull(ctx, config.PrinkDockerImage, image.PullOptions{})
 	if err := exec.Command("docker", "-H", dockerHost, "pull", config.PrinkDockerImage).Run(); err != nil {
-		return err
+		return failure.Errorf(failure.ImagePull, "could not pull %s: %v", config.PrinkDockerImage, err)
 	}
 
 	networkName := "prink-eval" + experiment.ToFileName()
-	net, err := cli.NetworkCreate(ctx, networkName, network.CreateOptions{})
+	net, err := cli.NetworkCreate(ctx, networkName, network.CreateOptions{Labels: labels(experiment, config)})
 	if err != nil {
 		log.Print(err)
 	}
@@ -101,18 +154,19 @@ func StartPrink(experiment *types.Experiment, config types.Config) error {
 
 	log.Printf("Starting prink with command:%v", cmd)
 
-	exposedPorts := []string{"8081", "9249"}
+	// the REST API and the metrics keep their ports in the container, bound to the host ports assigned to the experiment
+	exposedPorts := map[string]int{"8081": experiment.RestPort, "9249": experiment.JobManagerMetricsPort}
 	exposedPortsDocker := nat.PortSet{}
-	for _, p := range exposedPorts {
+	for p := range exposedPorts {
 		exposedPortsDocker[nat.Port(p+"/tcp")] = struct{}{}
 	}
 
 	portBindings := nat.PortMap{}
-	for _, p := range exposedPorts {
+	for p, hostPort := range exposedPorts {
 		portBindings[nat.Port(p+"/tcp")] = []nat.PortBinding{
 			{
 				HostIP:   "0.0.0.0",
-				HostPort: p,
+				HostPort: fmt.Sprintf("%d", hostPort),
 			},
 		}
 	}
@@ -122,13 +176,15 @@ func StartPrink(experiment *types.Experiment, config types.Config) error {
 		Cmd:      cmd,
 		Tty:      false,
 		Hostname: "jobmanager",
+		Labels:   labels(experiment, config),
 		Env: []string{
 			`FLINK_PROPERTIES=
      jobmanager.rpc.address: jobmanager
 		 rest.profiling.enabled: true
 		 rest.flamegraph.enabled: true
 		 metrics.reporter.prom.factory.class: org.apache.flink.metrics.prometheus.PrometheusReporterFactory
-		 metrics.reporter.prom.port: 9249`,
+		 metrics.reporter.prom.port: 9249
+		 parallelism.default: ` + fmt.Sprintf("%d", experiment.Parallelism),
 		},
 		ExposedPorts: exposedPortsDocker,
 	}, &container.HostConfig{
@@ -146,54 +202,62 @@ func StartPrink(experiment *types.Experiment, config types.Config) error {
 
 	defer cli.ContainerRemove(ctx, containerJobManager.ID, container.RemoveOptions{Force: true})
 
-	containerTaskManager, err := cli.ContainerCreate(ctx, &container.Config{
-		Image:    config.PrinkDockerImage,
-		Cmd:      []string{"taskmanager"},
-		Tty:      false,
-		Hostname: "taskmanger",
-		Env: []string{
-			fmt.Sprintf(
-				`FLINK_PROPERTIES=
+	taskManagers := []string{}
+	for i := 0; i < experiment.TaskManagers; i++ {
+		// every taskmanager reports metrics on 9250 in its container, bound to consecutive host ports
+		metricsPort := fmt.Sprintf("%d", experiment.TaskManagerMetricsPort+i)
+
+		containerTaskManager, err := cli.ContainerCreate(ctx, &container.Config{
+			Image:    config.PrinkDockerImage,
+			Cmd:      []string{"taskmanager"},
+			Tty:      false,
+			Hostname: fmt.Sprintf("taskmanager-%d", i),
+			Labels:   labels(experiment, config),
+			Env: []string{
+				fmt.Sprintf(
+					`FLINK_PROPERTIES=
      jobmanager.rpc.address: jobmanager
 		 metrics.reporter.prom.factory.class: org.apache.flink.metrics.prometheus.PrometheusReporterFactory
 		 metrics.reporter.prom.port: 9250
 		 rest.profiling.enabled: true
 		 rest.flamegraph.enabled: true
-     taskmanager.numberOfTaskSlots: 1
-     taskmanager.memory.process.size: %s`, config.TaskManagerMemory),
-		},
-		ExposedPorts: nat.PortSet{
-			"9250/tcp": struct{}{},
-		},
-	}, &container.HostConfig{
-		PortBindings: nat.PortMap{
-			"9250/tcp": []nat.PortBinding{
-				{
-					HostIP:   "0.0.0.0",
-					HostPort: "9250",
+     taskmanager.numberOfTaskSlots: %d
+     taskmanager.memory.process.size: %s`, experiment.TaskSlots, config.TaskManagerMemory),
+			},
+			ExposedPorts: nat.PortSet{
+				"9250/tcp": struct{}{},
+			},
+		}, &container.HostConfig{
+			PortBindings: nat.PortMap{
+				"9250/tcp": []nat.PortBinding{
+					{
+						HostIP:   "0.0.0.0",
+						HostPort: metricsPort,
+					},
 				},
 			},
-		},
-	}, &network.NetworkingConfig{
-		EndpointsConfig: map[string]*network.EndpointSettings{
-			"network": {
-				NetworkID: networkName,
+		}, &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				"network": {
+					NetworkID: networkName,
+				},
 			},
-		},
-	}, nil, "")
-	defer cli.ContainerRemove(ctx, containerTaskManager.ID, container.RemoveOptions{Force: true})
-
-	if err != nil {
-		return err
-	}
+		}, nil, "")
+		if err != nil {
+			return err
+		}
+		defer cli.ContainerRemove(ctx, containerTaskManager.ID, container.RemoveOptions{Force: true})
+		taskManagers = append(taskManagers, containerTaskManager.ID)
 
-	if err := cli.ContainerStart(ctx, containerTaskManager.ID, container.StartOptions{}); err != nil {
-		return err
+		if err := startContainer(ctx, cli, containerTaskManager.ID); err != nil {
+			return err
+		}
 	}
 
-	if err := cli.ContainerStart(ctx, containerJobManager.ID, container.StartOptions{}); err != nil {
+	if err := startContainer(ctx, cli, containerJobManager.ID); err != nil {
 		return err
 	}
+	close(started)
 
 	statusCh, errCh := cli.ContainerWait(ctx, containerJobManager.ID, container.WaitConditionNextExit)
 	var containerError error
@@ -204,8 +268,10 @@ func StartPrink(experiment *types.Experiment, config types.Config) error {
 		}
 	case res := <-statusCh:
 		if res.StatusCode != 0 {
-			containerError = fmt.Errorf("jobmanager exited with status %d", res.StatusCode)
+			containerError = failure.Errorf(failure.ContainerExit, "jobmanager exited with status %d", res.StatusCode)
 		}
+	case <-stop.Done():
+		containerError = fmt.Errorf("jobmanager stopped: %v", context.Cause(stop))
 	}
 
 	logJobManager, err := cli.ContainerLogs(ctx, containerJobManager.ID, container.LogsOptions{ShowStdout: true})
@@ -213,13 +279,25 @@ func StartPrink(experiment *types.Experiment, config types.Config) error {
 		writeLogs(config.OutputFolder+"/flink_job_manager-"+time.Now().Format("2006-01-02.15:04:05")+experiment.ToFileName()+".log", &logJobManager)
 	}
 
-	logTaskManager, err := cli.ContainerLogs(ctx, containerTaskManager.ID, container.LogsOptions{ShowStdout: true})
-	if err == nil {
-		writeLogs(config.OutputFolder+"/flink_task_manager-"+time.Now().Format("2006-01-02.15:04:05")+experiment.ToFileName()+".log", &logTaskManager)
+	for i, id := range taskManagers {
+		logTaskManager, err := cli.ContainerLogs(ctx, id, container.LogsOptions{ShowStdout: true})
+		if err == nil {
+			writeLogs(fmt.Sprintf("%s/flink_task_manager_%d-%s%s.log", config.OutputFolder, i, time.Now().Format("2006-01-02.15:04:05"), experiment.ToFileName()), &logTaskManager)
+		}
 	}
 	return containerError
 }
 
+// startContainer starts the container. As the client cannot probe the ports of the SUT host when it assigns them,
+// failing to bind a host port is classified as failure.PortConflict, so the experiment is retried with other ports.
+func startContainer(ctx context.Context, cli *client.Client, id string) error {
+	err := cli.ContainerStart(ctx, id, container.StartOptions{})
+	if err != nil && (strings.Contains(err.Error(), "port is already allocated") || strings.Contains(err.Error(), "address already in use")) {
+		return failure.New(failure.PortConflict, err)
+	}
+	return err
+}
+
 func writeLogs(filename string, src *io.ReadCloser) {
 	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
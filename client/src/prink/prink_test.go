@@ -0,0 +1,86 @@
+package prink
+
+import (
+	"prinkbenchmarking/src/types"
+	"slices"
+	"testing"
+)
+
+func TestCleanupFilter(t *testing.T) {
+	experiment := types.DefaultExperiment()
+	other := types.DefaultExperiment()
+	other.K = 10
+
+	tests := []struct {
+		name       string
+		filter     CleanupFilter
+		wantLabels []string
+		// whether the resources of the experiment and another one in campaign a, and of the experiment in campaign b are removed
+		wantExperiment bool
+		wantOther      bool
+		wantCampaign   bool
+	}{
+		{
+			name:           "all campaigns",
+			filter:         CleanupFilter{},
+			wantLabels:     []string{LabelCampaign},
+			wantExperiment: true,
+			wantOther:      true,
+			wantCampaign:   true,
+		},
+		{
+			name:           "campaign",
+			filter:         CleanupFilter{Campaign: "a"},
+			wantLabels:     []string{LabelCampaign, LabelCampaign + "=a"},
+			wantExperiment: true,
+			wantOther:      true,
+		},
+		{
+			name:           "experiment of a campaign",
+			filter:         CleanupFilter{Campaign: "a", Experiment: experiment.ToFileName()},
+			wantLabels:     []string{LabelCampaign, LabelCampaign + "=a", LabelExperiment + "=" + experiment.ToFileName()},
+			wantExperiment: true,
+		},
+		{
+			name:           "experiment of all campaigns",
+			filter:         CleanupFilter{Experiment: experiment.ToFileName()},
+			wantLabels:     []string{LabelCampaign, LabelExperiment + "=" + experiment.ToFileName()},
+			wantExperiment: true,
+			wantCampaign:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := test.filter.args()
+
+			got := args.Get("label")
+			slices.Sort(got)
+			want := slices.Clone(test.wantLabels)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("got label filters %v, want %v", got, want)
+			}
+
+			for _, resource := range []struct {
+				name       string
+				experiment types.Experiment
+				campaign   string
+				want       bool
+			}{
+				{"experiment", experiment, "a", test.wantExperiment},
+				{"other experiment", other, "a", test.wantOther},
+				{"other campaign", experiment, "b", test.wantCampaign},
+			} {
+				resourceLabels := labels(&resource.experiment, types.Config{CampaignID: resource.campaign})
+				if matched := args.MatchKVList("label", resourceLabels); matched != resource.want {
+					t.Errorf("%s matched: %v, want %v", resource.name, matched, resource.want)
+				}
+			}
+
+			if args.MatchKVList("label", map[string]string{"com.docker.compose.project": "a"}) {
+				t.Errorf("matched a container which was not created by StartPrink")
+			}
+		})
+	}
+}
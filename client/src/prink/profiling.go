@@ -1,9 +1,6 @@
 package prink
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
 	"prinkbenchmarking/src/types"
 )
 
@@ -74,37 +71,18 @@ type FlamegraphResponse struct {
 }
 
 
-func GetProfilingData(experiment *types.Experiment, config types.Config) (*Flamegraph, error) {
-
-	job_response, err := http.Get("http://" + experiment.SutHost + ":8081/jobs/overview")
+// GetProfilingData returns the current flamegraph of the Prink operator.
+// Flink samples the flamegraph periodically, consecutive calls may return the same sample with the same EndTimestamp.
+func GetProfilingData(experiment *types.Experiment, config types.Config) (*FlamegraphResponse, error) {
+	jobDetails, err := runningJob(experiment)
 	if err != nil {
 		return nil, err
 	}
 
-	jobs := JobOverview{}
-	// json
-	json.NewDecoder(job_response.Body).Decode(&jobs)
-
-	if len(jobs.Jobs) == 0 || jobs.Jobs[0].State != "RUNNING" {
-		return nil, fmt.Errorf("job not finished")
-	}
-	
-	// get the job id
-	jobId := jobs.Jobs[0].JID
-
-	job_details_response, err := http.Get("http://" + experiment.SutHost + ":8081/jobs/" + jobId)
-	if err != nil {
-		return nil, err
-	}
-
-	jobDetails := JobDetails{}
-	// json
-	json.NewDecoder(job_details_response.Body).Decode(&jobDetails)
-
 	// get the vertex with the name starting with 'k'
 	var vertex Vertex
 	for _, v := range jobDetails.Vertices {
-		if v.Name[0] == 'k' {
+		if len(v.Name) > 0 && v.Name[0] == 'k' {
 			vertex = v
 			break
 		}
@@ -113,14 +91,10 @@ func GetProfilingData(experiment *types.Experiment, config types.Config) (*Flame
 	//http://localhost:8081/jobs/145c3014963ee48bca4954e75c2ae369/vertices/4150b807e25f98bebfeb73f2fab67d53/flamegraph?type=on_cpu
 
 	// get the flamegraph
-	flamegraph_response, err := http.Get("http://" + experiment.SutHost + ":8081/jobs/" + jobId + "/vertices/" + vertex.ID + "/flamegraph?type=on_cpu")	
-	if err != nil {
+	flamegraph := FlamegraphResponse{}
+	if err := getJSON(restURL(experiment)+"/jobs/"+jobDetails.JID+"/vertices/"+vertex.ID+"/flamegraph?type=on_cpu", &flamegraph); err != nil {
 		return nil, err
 	}
 
-	// json
-	flamegraph := FlamegraphResponse{}
-	json.NewDecoder(flamegraph_response.Body).Decode(&flamegraph)
-
-	return &flamegraph.Data, nil
+	return &flamegraph, nil
 }
@@ -0,0 +1,101 @@
+package prink
+
+import (
+	"context"
+	"fmt"
+	"prinkbenchmarking/src/failure"
+	"prinkbenchmarking/src/types"
+	"strings"
+	"time"
+)
+
+// readinessInterval is how often the job overview is polled while waiting for the job.
+const readinessInterval = 500 * time.Millisecond
+
+// JobExceptions is the exception history of a job as returned by /jobs/{jid}/exceptions.
+type JobExceptions struct {
+	RootException    string `json:"root-exception"`
+	ExceptionHistory struct {
+		Entries []JobException `json:"entries"`
+	} `json:"exceptionHistory"`
+}
+
+// JobException is an entry of the exception history of a job.
+type JobException struct {
+	ExceptionName string `json:"exceptionName"`
+	Stacktrace    string `json:"stacktrace"`
+	Timestamp     int64  `json:"timestamp"`
+	TaskName      string `json:"taskName"`
+}
+
+// JobFailedError is returned by WaitForJob if the job failed before it was running.
+type JobFailedError struct {
+	JID        string
+	State      string
+	Exceptions *JobExceptions
+}
+
+func (e *JobFailedError) Error() string {
+	message := fmt.Sprintf("job %s is %s", e.JID, e.State)
+	if e.Exceptions == nil {
+		return message
+	}
+	for _, entry := range e.Exceptions.ExceptionHistory.Entries {
+		// the first line of the stack trace is the exception and its message
+		cause, _, _ := strings.Cut(entry.Stacktrace, "\n")
+		if cause == "" {
+			cause = entry.ExceptionName
+		}
+		message += fmt.Sprintf("; %s: %s", entry.TaskName, cause)
+	}
+	if len(e.Exceptions.ExceptionHistory.Entries) == 0 && e.Exceptions.RootException != "" {
+		cause, _, _ := strings.Cut(e.Exceptions.RootException, "\n")
+		message += "; " + cause
+	}
+	return message
+}
+
+// WaitForJob polls the job overview of the experiment's SUT until the Prink job is running and returns how long it took.
+// It fails with a JobFailedError as soon as the job failed or was cancelled, and with an error if the job is not running within the timeout,
+// both of class failure.JobFailed.
+func WaitForJob(ctx context.Context, experiment *types.Experiment, timeout time.Duration) (time.Duration, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(readinessInterval)
+	defer ticker.Stop()
+
+	var lastError error
+	for {
+		jobs := JobOverview{}
+		// the REST API is not reachable until the jobmanager is up
+		if err := getJSON(restURL(experiment)+"/jobs/overview", &jobs); err != nil {
+			lastError = err
+		} else if len(jobs.Jobs) == 0 {
+			lastError = fmt.Errorf("no job submitted")
+		} else {
+			job := jobs.Jobs[0]
+			switch job.State {
+			case "RUNNING", "FINISHED":
+				return time.Since(start), nil
+			case "FAILED", "FAILING", "CANCELED", "CANCELLING":
+				exceptions := &JobExceptions{}
+				if err := getJSON(restURL(experiment)+"/jobs/"+job.JID+"/exceptions", exceptions); err != nil {
+					exceptions = nil
+				}
+				return time.Since(start), failure.New(failure.JobFailed, &JobFailedError{JID: job.JID, State: job.State, Exceptions: exceptions})
+			}
+			lastError = fmt.Errorf("job %s is %s", job.JID, job.State)
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return time.Since(start), failure.Errorf(failure.JobFailed, "job not running after %v: %v", timeout, lastError)
+			}
+			return time.Since(start), ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package prink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"prinkbenchmarking/src/types"
+	"strconv"
+	"time"
+)
+
+// restClient queries the Flink REST API, a stuck jobmanager must not block the experiment.
+var restClient = &http.Client{Timeout: 5 * time.Second}
+
+// restURL returns the base URL of the Flink REST API of the experiment's SUT.
+func restURL(experiment *types.Experiment) string {
+	return "http://" + net.JoinHostPort(experiment.SutHost, strconv.Itoa(experiment.RestPort))
+}
+
+// getJSON decodes the response of a GET request to the Flink REST API into v.
+func getJSON(url string, v any) error {
+	response, err := restClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+// runningJob returns the details of the job running on the experiment's SUT.
+func runningJob(experiment *types.Experiment) (*JobDetails, error) {
+	jobs := JobOverview{}
+	if err := getJSON(restURL(experiment)+"/jobs/overview", &jobs); err != nil {
+		return nil, err
+	}
+
+	if len(jobs.Jobs) == 0 || jobs.Jobs[0].State != "RUNNING" {
+		return nil, fmt.Errorf("job not running")
+	}
+
+	jobDetails := JobDetails{}
+	if err := getJSON(restURL(experiment)+"/jobs/"+jobs.Jobs[0].JID, &jobDetails); err != nil {
+		return nil, err
+	}
+	return &jobDetails, nil
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// Point is a value of a chart at an experiment parameter value.
+type Point struct {
+	X int
+	Y float64
+}
+
+// Chart is a line chart of a metric over the values of an experiment parameter.
+type Chart struct {
+	Name   string
+	Title  string
+	XLabel string
+	YLabel string
+	Points []Point
+}
+
+const (
+	chartWidth  = 480
+	chartHeight = 300
+	chartMargin = 50
+)
+
+// SVG renders the chart as a standalone SVG image.
+// The parameter values are spaced evenly, as campaigns usually grow them exponentially.
+func (c Chart) SVG() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="white"/>`, chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<text x="%d" y="20" text-anchor="middle" font-size="14">%s</text>`, chartWidth/2, html.EscapeString(c.Title))
+
+	left, right := float64(chartMargin), float64(chartWidth-chartMargin/2)
+	top, bottom := float64(chartMargin), float64(chartHeight-chartMargin)
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`, left, bottom, right, bottom)
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`, left, top, left, bottom)
+	fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, (left+right)/2, chartHeight-10, html.EscapeString(c.XLabel))
+	fmt.Fprintf(&b, `<text x="12" y="%.1f" text-anchor="middle" transform="rotate(-90 12 %.1f)">%s</text>`, (top+bottom)/2, (top+bottom)/2, html.EscapeString(c.YLabel))
+
+	if len(c.Points) == 0 {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">no data</text>`, (left+right)/2, (top+bottom)/2)
+		b.WriteString(`</svg>`)
+		return b.String()
+	}
+
+	maximum := 0.0
+	for _, p := range c.Points {
+		maximum = math.Max(maximum, p.Y)
+	}
+	if maximum == 0 {
+		maximum = 1
+	}
+
+	x := func(i int) float64 {
+		if len(c.Points) == 1 {
+			return (left + right) / 2
+		}
+		return left + 20 + float64(i)*(right-left-40)/float64(len(c.Points)-1)
+	}
+	y := func(v float64) float64 {
+		return bottom - v/maximum*(bottom-top)
+	}
+
+	// y axis from 0 to the maximum in quarters
+	for i := 0; i <= 4; i++ {
+		v := maximum * float64(i) / 4
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ddd"/>`, left, y(v), right, y(v))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end">%.3g</text>`, left-4, y(v)+4, v)
+	}
+
+	line := []string{}
+	for i, p := range c.Points {
+		line = append(line, fmt.Sprintf("%.1f,%.1f", x(i), y(p.Y)))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">%d</text>`, x(i), bottom+15, p.X)
+	}
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="steelblue" stroke-width="2"/>`, strings.Join(line, " "))
+	for i, p := range c.Points {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="steelblue"><title>%d: %.3f</title></circle>`, x(i), y(p.Y), p.X, p.Y)
+	}
+
+	b.WriteString(`</svg>`)
+	return b.String()
+}
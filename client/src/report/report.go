@@ -0,0 +1,322 @@
+// Package report renders the results of a campaign as a self-contained HTML and Markdown report.
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"prinkbenchmarking/src/analysis"
+	"prinkbenchmarking/src/evaluation"
+	"prinkbenchmarking/src/types"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Report holds everything rendered into the report of a campaign.
+type Report struct {
+	Campaign  string
+	Generated time.Time
+	Folder    string
+
+	Summaries  []analysis.Summary
+	Parameters []ParameterTable
+	Charts     []Chart
+	Failed     []evaluation.JournalEntry
+	Artifacts  []Artifacts
+}
+
+// ParameterTable aggregates the runs by the values of one experiment parameter.
+type ParameterTable struct {
+	Parameter string
+	Rows      []ParameterRow
+}
+
+// ParameterRow holds the means over all runs with the same value of a parameter.
+type ParameterRow struct {
+	Value       int
+	Runs        int
+	LatencyP50  float64
+	LatencyP99  float64
+	Throughput  float64
+	InfoLoss    float64
+	KViolations int
+	LViolations int
+}
+
+// Artifacts links the flamegraphs (JSON and folded stacks) and Flink logs of an experiment, relative to the report folder.
+type Artifacts struct {
+	Experiment  string
+	Flamegraphs []string
+	Logs        []string
+}
+
+// chartSpecs are the charts of the report: a metric plotted against an experiment parameter.
+var chartSpecs = []struct {
+	name      string
+	title     string
+	parameter string
+	unit      string
+	value     func(row ParameterRow) float64
+}{
+	{"latency_vs_k", "Latency (p50) vs k", "k", "ms", func(row ParameterRow) float64 { return row.LatencyP50 }},
+	{"throughput_vs_delta", "Throughput vs delta", "delta", "records/s", func(row ParameterRow) float64 { return row.Throughput }},
+	{"info_loss_vs_l", "Information loss vs l", "l", "", func(row ParameterRow) float64 { return row.InfoLoss }},
+}
+
+// Generate analyzes the results in the output folder and returns the report of the campaign.
+func Generate(config types.Config) (*Report, error) {
+	summaries, err := analysis.Analyze(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not analyze results: %v", err)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Experiment.ToFileName() < summaries[j].Experiment.ToFileName()
+	})
+
+	journal, err := evaluation.OpenJournal(config.OutputFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Campaign:  config.CampaignID,
+		Generated: time.Now(),
+		Folder:    config.OutputFolder,
+		Summaries: summaries,
+	}
+
+	for _, parameter := range types.ExperimentParameters() {
+		table := parameterTable(parameter, summaries)
+		// parameters which were not varied only add noise
+		if len(table.Rows) > 1 {
+			report.Parameters = append(report.Parameters, table)
+		}
+	}
+
+	for _, spec := range chartSpecs {
+		table := parameterTable(spec.parameter, summaries)
+		chart := Chart{Name: spec.name, Title: spec.title, XLabel: spec.parameter, YLabel: spec.unit}
+		for _, row := range table.Rows {
+			chart.Points = append(chart.Points, Point{X: row.Value, Y: spec.value(row)})
+		}
+		report.Charts = append(report.Charts, chart)
+	}
+
+	for _, entry := range journal.Entries() {
+		if entry.State != evaluation.StateSucceeded && entry.Tries > 0 {
+			report.Failed = append(report.Failed, entry)
+		}
+	}
+
+	report.Artifacts, err = findArtifacts(config.OutputFolder, summaries, journal.Entries())
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func parameterTable(parameter string, summaries []analysis.Summary) ParameterTable {
+	rows := map[int]*ParameterRow{}
+	for _, s := range summaries {
+		value := s.Parameters[parameter]
+		row, ok := rows[value]
+		if !ok {
+			row = &ParameterRow{Value: value}
+			rows[value] = row
+		}
+		row.Runs++
+		row.LatencyP50 += s.Latency.P50
+		row.LatencyP99 += s.Latency.P99
+		row.Throughput += s.Throughput
+		row.InfoLoss += s.InfoLoss.Mean
+		row.KViolations += s.KViolations
+		row.LViolations += s.LViolations
+	}
+
+	table := ParameterTable{Parameter: parameter}
+	for _, row := range rows {
+		runs := float64(row.Runs)
+		row.LatencyP50 /= runs
+		row.LatencyP99 /= runs
+		row.Throughput /= runs
+		row.InfoLoss /= runs
+		table.Rows = append(table.Rows, *row)
+	}
+	sort.Slice(table.Rows, func(i, j int) bool { return table.Rows[i].Value < table.Rows[j].Value })
+	return table
+}
+
+// findArtifacts collects the flamegraphs and Flink logs written into the output folder for every experiment.
+func findArtifacts(folder string, summaries []analysis.Summary, entries []evaluation.JournalEntry) ([]Artifacts, error) {
+	names := map[string]bool{}
+	for _, s := range summaries {
+		names[s.Experiment.ToFileName()] = true
+	}
+	for _, entry := range entries {
+		names[entry.Experiment] = true
+	}
+
+	files, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []Artifacts{}
+	for name := range names {
+		a := Artifacts{Experiment: name}
+		for _, file := range files {
+			link := "../" + file.Name()
+			switch {
+			case strings.HasPrefix(file.Name(), "flamegraph-") &&
+				(strings.HasSuffix(file.Name(), "."+name+".json") || strings.HasSuffix(file.Name(), "."+name+".folded")):
+				a.Flamegraphs = append(a.Flamegraphs, link)
+			case strings.HasPrefix(file.Name(), "flink_") && strings.HasSuffix(file.Name(), name+".log"):
+				a.Logs = append(a.Logs, link)
+			}
+		}
+		if len(a.Flamegraphs) > 0 || len(a.Logs) > 0 {
+			artifacts = append(artifacts, a)
+		}
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Experiment < artifacts[j].Experiment })
+
+	return artifacts, nil
+}
+
+// Save writes the report as report.html and report.md with the charts as SVG files into the folder.
+func (r *Report) Save(folder string) error {
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return fmt.Errorf("could not create report directory: %v", err)
+	}
+
+	for _, chart := range r.Charts {
+		if err := os.WriteFile(filepath.Join(folder, chart.Name+".svg"), []byte(chart.SVG()), 0644); err != nil {
+			return err
+		}
+	}
+
+	html, err := os.Create(filepath.Join(folder, "report.html"))
+	if err != nil {
+		return err
+	}
+	defer html.Close()
+	if err := htmlReport.Execute(html, r); err != nil {
+		return fmt.Errorf("could not render HTML report: %v", err)
+	}
+
+	markdown, err := os.Create(filepath.Join(folder, "report.md"))
+	if err != nil {
+		return err
+	}
+	defer markdown.Close()
+	if err := markdownReport.Execute(markdown, r); err != nil {
+		return fmt.Errorf("could not render Markdown report: %v", err)
+	}
+
+	return nil
+}
+
+var templateFuncs = map[string]any{
+	"float": func(v float64) string { return fmt.Sprintf("%.3f", v) },
+	"time":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"base":  filepath.Base,
+	"ext":   filepath.Ext,
+	// the HTML report embeds the charts, so it can be shared as a single file
+	"svg": func(chart Chart) htmltemplate.HTML { return htmltemplate.HTML(chart.SVG()) },
+}
+
+var htmlReport = htmltemplate.Must(htmltemplate.New("report.html").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Prink benchmark report: {{.Campaign}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.6em; text-align: right; }
+th { background: #f0f0f0; }
+td.name { text-align: left; font-family: monospace; }
+.charts svg { margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>Prink benchmark report: {{.Campaign}}</h1>
+<p>Generated {{time .Generated}} from <code>{{.Folder}}</code>, {{len .Summaries}} experiment runs.</p>
+
+<h2>Charts</h2>
+<div class="charts">
+{{range .Charts}}{{svg .}}
+{{end}}</div>
+
+<h2>Parameters</h2>
+{{range .Parameters}}<h3>{{.Parameter}}</h3>
+<table>
+<tr><th>{{.Parameter}}</th><th>runs</th><th>latency p50 (ms)</th><th>latency p99 (ms)</th><th>throughput (records/s)</th><th>info loss</th><th>k violations</th><th>l violations</th></tr>
+{{range .Rows}}<tr><td>{{.Value}}</td><td>{{.Runs}}</td><td>{{float .LatencyP50}}</td><td>{{float .LatencyP99}}</td><td>{{float .Throughput}}</td><td>{{float .InfoLoss}}</td><td>{{.KViolations}}</td><td>{{.LViolations}}</td></tr>
+{{end}}</table>
+{{else}}<p>No parameter was varied.</p>
+{{end}}
+<h2>Experiments</h2>
+<table>
+<tr><th>experiment</th><th>records</th><th>duration (s)</th><th>throughput (records/s)</th><th>latency p50 (ms)</th><th>latency p99 (ms)</th><th>info loss</th><th>k violations</th><th>l violations</th></tr>
+{{range .Summaries}}<tr><td class="name">{{.Experiment.ToFileName}}</td><td>{{.Records}}</td><td>{{float .Duration}}</td><td>{{float .Throughput}}</td><td>{{float .Latency.P50}}</td><td>{{float .Latency.P99}}</td><td>{{float .InfoLoss.Mean}}</td><td>{{.KViolations}}</td><td>{{.LViolations}}</td></tr>
+{{end}}</table>
+
+<h2>Failed experiments</h2>
+{{if .Failed}}<table>
+<tr><th>experiment</th><th>state</th><th>tries</th><th>updated</th></tr>
+{{range .Failed}}<tr><td class="name">{{.Experiment}}</td><td>{{.State}}</td><td>{{.Tries}}</td><td>{{time .Updated}}</td></tr>
+{{end}}</table>
+{{else}}<p>None.</p>
+{{end}}
+<h2>Flamegraphs and logs</h2>
+{{if .Artifacts}}<ul>
+{{range .Artifacts}}<li><code>{{.Experiment}}</code>:{{range .Flamegraphs}} <a href="{{.}}">flamegraph{{ext .}}</a>{{end}}{{range .Logs}} <a href="{{.}}">{{base .}}</a>{{end}}</li>
+{{end}}</ul>
+{{else}}<p>None.</p>
+{{end}}</body>
+</html>
+`))
+
+var markdownReport = template.Must(template.New("report.md").Funcs(templateFuncs).Parse(`# Prink benchmark report: {{.Campaign}}
+
+Generated {{time .Generated}} from ` + "`{{.Folder}}`" + `, {{len .Summaries}} experiment runs.
+
+## Charts
+{{range .Charts}}
+![{{.Title}}]({{.Name}}.svg)
+{{end}}
+## Parameters
+{{range .Parameters}}
+### {{.Parameter}}
+
+| {{.Parameter}} | runs | latency p50 (ms) | latency p99 (ms) | throughput (records/s) | info loss | k violations | l violations |
+|---|---|---|---|---|---|---|---|
+{{range .Rows}}| {{.Value}} | {{.Runs}} | {{float .LatencyP50}} | {{float .LatencyP99}} | {{float .Throughput}} | {{float .InfoLoss}} | {{.KViolations}} | {{.LViolations}} |
+{{end}}{{else}}
+No parameter was varied.
+{{end}}
+## Experiments
+
+| experiment | records | duration (s) | throughput (records/s) | latency p50 (ms) | latency p99 (ms) | info loss | k violations | l violations |
+|---|---|---|---|---|---|---|---|---|
+{{range .Summaries}}| {{.Experiment.ToFileName}} | {{.Records}} | {{float .Duration}} | {{float .Throughput}} | {{float .Latency.P50}} | {{float .Latency.P99}} | {{float .InfoLoss.Mean}} | {{.KViolations}} | {{.LViolations}} |
+{{end}}
+## Failed experiments
+{{if .Failed}}
+| experiment | state | tries | updated |
+|---|---|---|---|
+{{range .Failed}}| {{.Experiment}} | {{.State}} | {{.Tries}} | {{time .Updated}} |
+{{end}}{{else}}
+None.
+{{end}}
+## Flamegraphs and logs
+{{if .Artifacts}}
+{{range .Artifacts}}- ` + "`{{.Experiment}}`" + `:{{range .Flamegraphs}} [flamegraph{{ext .}}]({{.}}){{end}}{{range .Logs}} [{{base .}}]({{.}}){{end}}
+{{end}}{{else}}
+None.
+{{end}}`))
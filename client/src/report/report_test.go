@@ -0,0 +1,131 @@
+package report
+
+import (
+	"os"
+	"prinkbenchmarking/src/analysis"
+	"prinkbenchmarking/src/evaluation"
+	"prinkbenchmarking/src/types"
+	"reflect"
+	"testing"
+)
+
+// summary returns the summary of a run of the default experiment with the given k.
+func summary(k int, p50 float64, kViolations int) analysis.Summary {
+	e := types.DefaultExperiment()
+	e.K = k
+	return analysis.Summary{
+		Experiment:  e,
+		Parameters:  map[string]int{"k": k, "l": e.L},
+		Latency:     analysis.Distribution{P50: p50, P99: 2 * p50},
+		Throughput:  100,
+		InfoLoss:    analysis.Distribution{Mean: 0.5},
+		KViolations: kViolations,
+	}
+}
+
+func TestParameterTable(t *testing.T) {
+	tests := []struct {
+		name      string
+		parameter string
+		summaries []analysis.Summary
+		want      []ParameterRow
+	}{
+		{name: "no runs", parameter: "k"},
+		{
+			name:      "runs are averaged",
+			parameter: "k",
+			summaries: []analysis.Summary{summary(5, 10, 0), summary(5, 30, 1)},
+			want:      []ParameterRow{{Value: 5, Runs: 2, LatencyP50: 20, LatencyP99: 40, Throughput: 100, InfoLoss: 0.5, KViolations: 1}},
+		},
+		{
+			name:      "ordered numerically",
+			parameter: "k",
+			summaries: []analysis.Summary{summary(10, 20, 0), summary(5, 10, 0)},
+			want: []ParameterRow{
+				{Value: 5, Runs: 1, LatencyP50: 10, LatencyP99: 20, Throughput: 100, InfoLoss: 0.5},
+				{Value: 10, Runs: 1, LatencyP50: 20, LatencyP99: 40, Throughput: 100, InfoLoss: 0.5},
+			},
+		},
+		{
+			name:      "parameter which was not varied",
+			parameter: "l",
+			summaries: []analysis.Summary{summary(5, 10, 0), summary(10, 30, 0)},
+			want:      []ParameterRow{{Value: 0, Runs: 2, LatencyP50: 20, LatencyP99: 40, Throughput: 100, InfoLoss: 0.5}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table := parameterTable(test.parameter, test.summaries)
+			if table.Parameter != test.parameter {
+				t.Errorf("got table of %s, want %s", table.Parameter, test.parameter)
+			}
+			if len(table.Rows) != len(test.want) || (len(test.want) > 0 && !reflect.DeepEqual(table.Rows, test.want)) {
+				t.Errorf("got rows %+v, want %+v", table.Rows, test.want)
+			}
+		})
+	}
+}
+
+func TestFindArtifacts(t *testing.T) {
+	k5 := summary(5, 10, 0)
+	k15 := summary(15, 10, 0)
+	name := k5.Experiment.ToFileName()
+	other := k15.Experiment.ToFileName()
+
+	tests := []struct {
+		name      string
+		files     []string
+		summaries []analysis.Summary
+		entries   []evaluation.JournalEntry
+		want      []Artifacts
+	}{
+		{
+			name:      "flamegraphs and logs",
+			files:     []string{"flamegraph-2024-01-01.12:00:00." + name + ".json", "flamegraph-2024-01-01.12:00:00." + name + ".folded", "flink_job_manager-2024-01-01.12:00:00" + name + ".log"},
+			summaries: []analysis.Summary{k5},
+			want: []Artifacts{{
+				Experiment:  name,
+				Flamegraphs: []string{"../flamegraph-2024-01-01.12:00:00." + name + ".folded", "../flamegraph-2024-01-01.12:00:00." + name + ".json"},
+				Logs:        []string{"../flink_job_manager-2024-01-01.12:00:00" + name + ".log"},
+			}},
+		},
+		{
+			name:    "experiments of the journal without results",
+			files:   []string{"flink_task_manager_0-2024-01-01.12:00:00" + name + ".log"},
+			entries: []evaluation.JournalEntry{{Experiment: name, State: evaluation.StateFailed}},
+			want:    []Artifacts{{Experiment: name, Logs: []string{"../flink_task_manager_0-2024-01-01.12:00:00" + name + ".log"}}},
+		},
+		{
+			name:      "experiments with a similar name",
+			files:     []string{"flink_job_manager-2024-01-01.12:00:00" + other + ".log"},
+			summaries: []analysis.Summary{k5, k15},
+			want:      []Artifacts{{Experiment: other, Logs: []string{"../flink_job_manager-2024-01-01.12:00:00" + other + ".log"}}},
+		},
+		{
+			name:      "experiments without artifacts",
+			files:     []string{"results.csv", "manifest." + name + ".json"},
+			summaries: []analysis.Summary{k5},
+			want:      []Artifacts{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			folder := t.TempDir()
+			for _, file := range test.files {
+				if err := os.WriteFile(folder+"/"+file, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := findArtifacts(folder, test.summaries, test.entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got artifacts %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
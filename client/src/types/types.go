@@ -2,7 +2,10 @@ package types
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type Metric struct {
@@ -17,10 +20,238 @@ type Config struct {
 	PortRead                  int      `yaml:"sut_port_read"`
 	OutputFolder              string   `yaml:"output_folder"`
 	InputData                 string   `yaml:"input_data"`
+	// DatasetCacheMB is the memory up to which the parsed input data is kept in memory and shared by the experiments.
+	// Larger datasets are streamed from disk, 0 always streams.
+	DatasetCacheMB            int      `yaml:"dataset_cache_mb"`
 	TaskManagerMemory         string   `yaml:"taskmanager_memory"`
 	PrometheusExporterAddress string   `yaml:"prom-address"`
+	// MetricBuffer bounds the record samples the exporter buffers between two scrapes.
+	MetricBuffer MetricBuffer `yaml:"metric_buffer"`
+	// Push ships the record metrics to an import API instead of waiting for scrapes.
+	Push Push `yaml:"push"`
+	// PortRange is the range of ports assigned to the sockets, the Flink REST API and the metrics of the experiments.
+	PortRange PortRange `yaml:"port_range"`
 
 	PrinkDockerImage string `yaml:"prink_docker_image"`
+
+	// SutMode selects the system under test: "docker" runs Prink in Docker,
+	// "fake" runs the in-process stand-in from package fakesut.
+	SutMode string `yaml:"sut_mode"`
+	// StartupTimeout is how long an experiment waits for the Prink job to be running once its containers are started.
+	StartupTimeout time.Duration `yaml:"startup_timeout"`
+	// ExperimentTimeout is the maximum duration of an experiment, after which it is cancelled.
+	ExperimentTimeout ExperimentTimeout `yaml:"experiment_timeout"`
+	// Retry decides how often and after which delay failed experiments are tried again.
+	Retry Retry `yaml:"retry"`
+
+	// LoadProfile shapes the offered load of experiments with a target rate.
+	LoadProfile LoadProfile `yaml:"load_profile"`
+
+	// Window excludes the warm-up and cool-down of every experiment from the analysis and the metrics.
+	Window Window `yaml:"window"`
+
+	// Schema describes the columns of the input data, see config.DefaultSchema for the building dataset.
+	Schema Schema `yaml:"schema"`
+
+	// CampaignID labels the containers of the campaign, so cleanup only removes those.
+	CampaignID string `yaml:"campaign_id"`
+
+	// Campaign is the path to the campaign file declaring the experiment matrix.
+	// If empty, the built-in default matrix is used.
+	Campaign string `yaml:"campaign"`
+}
+
+// LoadProfile shapes the offered load over time as factors of the experiment's rate.
+type LoadProfile struct {
+	// Shape is one of "constant" (default), "step" or "ramp".
+	Shape string `yaml:"shape"`
+
+	// Steps are the factors applied one after another for StepDuration each.
+	// The last factor is kept until the dataset is exhausted.
+	Steps        []float64     `yaml:"steps"`
+	StepDuration time.Duration `yaml:"step_duration"`
+
+	// RampStart is the factor the ramp starts at, growing linearly to 1 over RampDuration.
+	RampStart    float64       `yaml:"ramp_start"`
+	RampDuration time.Duration `yaml:"ramp_duration"`
+}
+
+// MetricBuffer bounds the samples buffered per record metric of the exporter.
+type MetricBuffer struct {
+	// Capacity is the number of samples buffered per metric.
+	Capacity int `yaml:"capacity"`
+	// Policy decides which samples are dropped from a full buffer: "drop_oldest" (default), "drop_newest" or "downsample".
+	Policy string `yaml:"policy"`
+}
+
+// Push configures pushing the record metrics to the VictoriaMetrics import API.
+type Push struct {
+	// URL of the import API, e.g. http://victoriametrics:8428/api/v1/import/prometheus. Empty disables pushing.
+	URL string `yaml:"url"`
+	// BatchSize is the maximum number of samples per request.
+	BatchSize int `yaml:"batch_size"`
+	// Interval between two pushes, the samples are also pushed at the end of every experiment.
+	Interval time.Duration `yaml:"interval"`
+	// Retries of a failed request before its samples are dropped.
+	Retries int `yaml:"retries"`
+}
+
+// ExperimentTimeout limits the duration of an experiment to Base plus, for every parameter in PerParameter,
+// the parameter's value times the given duration, e.g. to give experiments with a large delta more time.
+type ExperimentTimeout struct {
+	// Base is the maximum duration of every experiment, 0 does not limit the duration.
+	Base         time.Duration            `yaml:"base"`
+	PerParameter map[string]time.Duration `yaml:"per_parameter"`
+}
+
+// Of returns the maximum duration of the experiment, or 0 if it is not limited.
+func (t ExperimentTimeout) Of(e Experiment) time.Duration {
+	if t.Base == 0 {
+		return 0
+	}
+	timeout := t.Base
+	for name, factor := range t.PerParameter {
+		if value, err := e.Parameter(name); err == nil {
+			timeout += time.Duration(*value) * factor
+		}
+	}
+	return timeout
+}
+
+// Retry is the retry policy of failed experiments, with overrides for the classes of failures of package failure.
+type Retry struct {
+	RetryPolicy `yaml:",inline"`
+	// Classes overrides the policy for failures of the given class, e.g. image_pull or timeout.
+	// Fields which are not set keep the value of the default policy.
+	Classes map[string]RetryPolicy `yaml:"classes"`
+}
+
+// RetryPolicy limits the tries of an experiment and delays its retries.
+type RetryPolicy struct {
+	// MaxTries is the number of tries after which a failed experiment is skipped.
+	MaxTries int `yaml:"max_tries"`
+	// Backoff is the delay before the first retry, doubled for every further try.
+	Backoff time.Duration `yaml:"backoff"`
+}
+
+// Policy returns the retry policy of experiments whose last try failed with the class.
+func (r Retry) Policy(class string) RetryPolicy {
+	policy := r.RetryPolicy
+	if override, ok := r.Classes[class]; ok {
+		if override.MaxTries > 0 {
+			policy.MaxTries = override.MaxTries
+		}
+		if override.Backoff > 0 {
+			policy.Backoff = override.Backoff
+		}
+	}
+	return policy
+}
+
+// Delay returns the delay before the next try of an experiment which failed tries times.
+func (p RetryPolicy) Delay(tries int) time.Duration {
+	if tries < 1 {
+		return 0
+	}
+	return p.Backoff << min(tries-1, 16)
+}
+
+// PortRange is a range of ports, both ends included. A Start of 0 stands for the configured PortWrite.
+type PortRange struct {
+	Start int `yaml:"start"`
+	End   int `yaml:"end"`
+}
+
+// Window splits the records of an experiment into a warm-up, a measurement and a cool-down phase.
+// Only records of the measurement phase are analyzed and exported as metrics.
+type Window struct {
+	// The warm-up lasts for the first WarmupRecords records and at least WarmupDuration after the first record was sent.
+	WarmupRecords  int           `yaml:"warmup_records"`
+	WarmupDuration time.Duration `yaml:"warmup_duration"`
+
+	// The cool-down covers the last CooldownRecords records of the dataset and, for experiments with a rate,
+	// the records intended to be sent in the last CooldownDuration of the load profile.
+	CooldownRecords  int           `yaml:"cooldown_records"`
+	CooldownDuration time.Duration `yaml:"cooldown_duration"`
+}
+
+// Phases of an experiment, written to the phase column of the results
+const (
+	PhaseWarmup      = "warmup"
+	PhaseMeasurement = "measurement"
+	PhaseCooldown    = "cooldown"
+)
+
+// Column roles of a schema
+const (
+	// RoleID identifies the individual a record belongs to, each equivalence class needs k of them.
+	RoleID = "id"
+	// RoleTimestamp is the event time of a record.
+	RoleTimestamp = "timestamp"
+	// RoleQuasiIdentifier columns are generalized by Prink and form the equivalence classes.
+	RoleQuasiIdentifier = "quasi_identifier"
+	// RoleSensitive needs l distinct values per equivalence class.
+	RoleSensitive = "sensitive"
+)
+
+// Column describes a column of the input data.
+type Column struct {
+	Name string `yaml:"name"`
+	// Type is one of "string", "int", "float" or "time".
+	Type string `yaml:"type"`
+	// Role is one of the Role constants, or empty.
+	Role string `yaml:"role"`
+	// Label adds the column as label to the record metrics.
+	Label bool `yaml:"label"`
+	// Export exports the numeric column as record metric.
+	Export bool `yaml:"export"`
+}
+
+// Schema describes the columns of the input data in the order of the dataset.
+// Prink returns the same columns, with generalized values for the quasi-identifiers.
+type Schema struct {
+	Columns []Column `yaml:"columns"`
+	// TimeLayout is the layout of columns of type time, time.DateTime if empty.
+	TimeLayout string `yaml:"time_layout"`
+}
+
+// Names returns the names of the columns.
+func (s Schema) Names() []string {
+	names := make([]string, len(s.Columns))
+	for i, column := range s.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// WithRole returns the names of the columns with the given role.
+func (s Schema) WithRole(role string) []string {
+	names := []string{}
+	for _, column := range s.Columns {
+		if column.Role == role {
+			names = append(names, column.Name)
+		}
+	}
+	return names
+}
+
+// Layout returns the layout of the columns of type time.
+func (s Schema) Layout() string {
+	if s.TimeLayout == "" {
+		return time.DateTime
+	}
+	return s.TimeLayout
+}
+
+// Campaign declares the experiment matrix of a benchmark campaign.
+// Every combination of the dimension values is run Repetitions times,
+// except for the combinations matching one of the Exclude entries.
+// Include lists additional points which are run on top of the matrix.
+type Campaign struct {
+	Repetitions int              `yaml:"repetitions"`
+	Dimensions  map[string][]int `yaml:"dimensions"`
+	Exclude     []map[string]int `yaml:"exclude"`
+	Include     []map[string]int `yaml:"include"`
 }
 
 type Experiment struct {
@@ -37,29 +268,131 @@ type Experiment struct {
 	Zeta  int
 	Mu    int
 
+	// Rate is the target rate in records per second, 0 sends as fast as possible.
+	Rate int
+
+	// Flink cluster: number of taskmanagers, task slots per taskmanager and parallelism of the job
+	TaskManagers int
+	TaskSlots    int
+	Parallelism  int
+
 	LocalHost    string
 	SutHost      string
 	SutPortWrite int
 	SutPortRead  int
 
+	// Host ports of the Flink REST API and the Prometheus reporters of the jobmanager and the taskmanagers on the SUT host,
+	// the taskmanagers use consecutive ports starting at TaskManagerMetricsPort
+	RestPort               int
+	JobManagerMetricsPort  int
+	TaskManagerMetricsPort int
+
 	RunId int
 	Try int
 }
 
+// DefaultExperiment returns an experiment with the parameters used when they are not set explicitly.
+func DefaultExperiment() Experiment {
+	return Experiment{
+		K:     5,
+		Delta: 20000,
+		L:     0,
+		Beta:  321728,
+		Zeta:  0,
+		Mu:    100,
+		Rate:  0,
+
+		TaskManagers: 1,
+		TaskSlots:    1,
+		Parallelism:  1,
+
+		RestPort:               8081,
+		JobManagerMetricsPort:  9249,
+		TaskManagerMetricsPort: 9250,
+	}
+}
+
+// ExperimentParameters returns the names of the parameters which can be varied in a campaign.
+func ExperimentParameters() []string {
+	return []string{"k", "delta", "l", "beta", "zeta", "mu", "rate", "taskmanagers", "slots", "parallelism"}
+}
+
+// Parameter returns a pointer to the field of the experiment parameter with the given name.
+func (e *Experiment) Parameter(name string) (*int, error) {
+	switch name {
+	case "k":
+		return &e.K, nil
+	case "delta":
+		return &e.Delta, nil
+	case "l":
+		return &e.L, nil
+	case "beta":
+		return &e.Beta, nil
+	case "zeta":
+		return &e.Zeta, nil
+	case "mu":
+		return &e.Mu, nil
+	case "rate":
+		return &e.Rate, nil
+	case "taskmanagers":
+		return &e.TaskManagers, nil
+	case "slots":
+		return &e.TaskSlots, nil
+	case "parallelism":
+		return &e.Parallelism, nil
+	}
+	return nil, fmt.Errorf("unknown experiment parameter %q", name)
+}
+
 func ExperimentKeys() []string {
-	return []string{"k", "delta", "l", "beta", "zeta", "mu", "run_id"}
+	return []string{"k", "delta", "l", "beta", "zeta", "mu", "rate", "taskmanagers", "slots", "parallelism", "run_id"}
 }
 
 func (e Experiment) ToLabels() []string {
-	return []string {fmt.Sprintf("%d", e.K), fmt.Sprintf("%d", e.Delta), fmt.Sprintf("%d", e.L), fmt.Sprintf("%d", e.Beta), fmt.Sprintf("%d", e.Zeta), fmt.Sprintf("%d", e.Mu), fmt.Sprintf("%d", e.RunId)}
+	return []string {fmt.Sprintf("%d", e.K), fmt.Sprintf("%d", e.Delta), fmt.Sprintf("%d", e.L), fmt.Sprintf("%d", e.Beta), fmt.Sprintf("%d", e.Zeta), fmt.Sprintf("%d", e.Mu), fmt.Sprintf("%d", e.Rate), fmt.Sprintf("%d", e.TaskManagers), fmt.Sprintf("%d", e.TaskSlots), fmt.Sprintf("%d", e.Parallelism), fmt.Sprintf("%d", e.RunId)}
 }
 
 func (e Experiment) String() string {
-	return fmt.Sprintf("Experiment: k=%d, delta=%d, l=%d, beta=%d, zeta=%d, mu=%d, run_id=%d, local_host=%s, sut_host=%s, sut_port_write=%d, sut_port_read=%d", e.K, e.Delta, e.L, e.Beta, e.Zeta, e.Mu, e.RunId, e.LocalHost, e.SutHost, e.SutPortWrite, e.SutPortRead)
+	return fmt.Sprintf("Experiment: k=%d, delta=%d, l=%d, beta=%d, zeta=%d, mu=%d, rate=%d, taskmanagers=%d, slots=%d, parallelism=%d, run_id=%d, local_host=%s, sut_host=%s, sut_port_write=%d, sut_port_read=%d, rest_port=%d, jobmanager_metrics_port=%d, taskmanager_metrics_port=%d", e.K, e.Delta, e.L, e.Beta, e.Zeta, e.Mu, e.Rate, e.TaskManagers, e.TaskSlots, e.Parallelism, e.RunId, e.LocalHost, e.SutHost, e.SutPortWrite, e.SutPortRead, e.RestPort, e.JobManagerMetricsPort, e.TaskManagerMetricsPort)
 }
 
 func (e Experiment) ToFileName() string {
-	return fmt.Sprintf("k%d_delta%d_l%d_beta%d_zeta%d_mu%d_run%d", e.K, e.Delta, e.L, e.Beta, e.Zeta, e.Mu, e.RunId)
+	return fmt.Sprintf("k%d_delta%d_l%d_beta%d_zeta%d_mu%d_rate%d_taskmanagers%d_slots%d_parallelism%d_run%d", e.K, e.Delta, e.L, e.Beta, e.Zeta, e.Mu, e.Rate, e.TaskManagers, e.TaskSlots, e.Parallelism, e.RunId)
+}
+
+// Validate checks that the Flink cluster of the experiment can run its job.
+func (e Experiment) Validate() error {
+	if e.TaskManagers < 1 || e.TaskSlots < 1 || e.Parallelism < 1 {
+		return fmt.Errorf("%s: taskmanagers, slots and parallelism must be at least 1", e.ToFileName())
+	}
+	if e.Parallelism > e.TaskManagers*e.TaskSlots {
+		return fmt.Errorf("%s: parallelism %d exceeds the %d task slots of the cluster", e.ToFileName(), e.Parallelism, e.TaskManagers*e.TaskSlots)
+	}
+	return nil
+}
+
+// ParseFileName returns the experiment encoded by ToFileName.
+// Parameters missing from the name keep their default value.
+func ParseFileName(name string) (Experiment, error) {
+	e := DefaultExperiment()
+	for _, token := range strings.Split(name, "_") {
+		key := strings.TrimRightFunc(token, unicode.IsDigit)
+		value, err := strconv.Atoi(token[len(key):])
+		if err != nil {
+			return e, fmt.Errorf("invalid token %q in experiment name %q", token, name)
+		}
+
+		if key == "run" {
+			e.RunId = value
+			continue
+		}
+		field, err := e.Parameter(key)
+		if err != nil {
+			return e, err
+		}
+		*field = value
+	}
+	return e, nil
 }
 
 func (e Experiment) ToArgs() []string {
@@ -71,6 +404,7 @@ func (e Experiment) ToArgs() []string {
 		"--zeta", fmt.Sprintf("%d", e.Zeta),
 		"--mu", fmt.Sprintf("%d", e.Mu),
 		"--run_id", fmt.Sprintf("%d", e.RunId),
+		"--parallelism", fmt.Sprintf("%d", e.Parallelism),
 		"--sut_host", e.LocalHost, // in the container, the SUT host is the local host
 		"--sut_port_write", fmt.Sprintf("%d", e.SutPortWrite),
 		"--sut_port_read", fmt.Sprintf("%d", e.SutPortRead),
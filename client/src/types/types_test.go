@@ -0,0 +1,122 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	retry := Retry{
+		RetryPolicy: RetryPolicy{MaxTries: 3, Backoff: time.Second},
+		Classes: map[string]RetryPolicy{
+			"image_pull": {MaxTries: 5, Backoff: time.Minute},
+			"timeout":    {MaxTries: 1},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		class        string
+		tries        int
+		wantMaxTries int
+		wantDelay    time.Duration
+	}{
+		{name: "not tried yet", class: "unknown", tries: 0, wantMaxTries: 3, wantDelay: 0},
+		{name: "first retry", class: "unknown", tries: 1, wantMaxTries: 3, wantDelay: time.Second},
+		{name: "doubled for every try", class: "unknown", tries: 3, wantMaxTries: 3, wantDelay: 4 * time.Second},
+		{name: "doubled at most 16 times", class: "unknown", tries: 40, wantMaxTries: 3, wantDelay: time.Second << 16},
+		{name: "class overrides both", class: "image_pull", tries: 2, wantMaxTries: 5, wantDelay: 2 * time.Minute},
+		{name: "class keeps the default backoff", class: "timeout", tries: 1, wantMaxTries: 1, wantDelay: time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := retry.Policy(test.class)
+			if policy.MaxTries != test.wantMaxTries {
+				t.Errorf("max tries of %s = %d, want %d", test.class, policy.MaxTries, test.wantMaxTries)
+			}
+			if delay := policy.Delay(test.tries); delay != test.wantDelay {
+				t.Errorf("delay after %d tries = %v, want %v", test.tries, delay, test.wantDelay)
+			}
+		})
+	}
+}
+
+func TestExperimentTopology(t *testing.T) {
+	tests := []struct {
+		name                             string
+		taskManagers, slots, parallelism int
+		wantErr                          string
+	}{
+		{name: "one slot", taskManagers: 1, slots: 1, parallelism: 1},
+		{name: "all slots", taskManagers: 2, slots: 2, parallelism: 4},
+		{name: "fewer tasks than slots", taskManagers: 2, slots: 4, parallelism: 3},
+		{name: "more tasks than slots", taskManagers: 2, slots: 1, parallelism: 3, wantErr: "exceeds the 2 task slots"},
+		{name: "no taskmanager", taskManagers: 0, slots: 1, parallelism: 1, wantErr: "at least 1"},
+		{name: "no slots", taskManagers: 1, slots: 0, parallelism: 1, wantErr: "at least 1"},
+		{name: "no parallelism", taskManagers: 1, slots: 1, parallelism: 0, wantErr: "at least 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := DefaultExperiment()
+			e.TaskManagers, e.TaskSlots, e.Parallelism = test.taskManagers, test.slots, test.parallelism
+
+			err := e.Validate()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, test.wantErr)
+			}
+
+			// the topology is part of the name, so the runs of different topologies do not overwrite each other
+			parsed, err := ParseFileName(e.ToFileName())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.TaskManagers != e.TaskManagers || parsed.TaskSlots != e.TaskSlots || parsed.Parallelism != e.Parallelism {
+				t.Errorf("parsed topology %d/%d/%d from %s", parsed.TaskManagers, parsed.TaskSlots, parsed.Parallelism, e.ToFileName())
+			}
+		})
+	}
+}
+
+func TestExperimentTimeout(t *testing.T) {
+	e := DefaultExperiment()
+	e.K, e.Delta = 10, 2000
+
+	tests := []struct {
+		name    string
+		timeout ExperimentTimeout
+		want    time.Duration
+	}{
+		{name: "unlimited", want: 0},
+		{name: "base only", timeout: ExperimentTimeout{Base: time.Minute}, want: time.Minute},
+		{
+			name:    "per parameter",
+			timeout: ExperimentTimeout{Base: time.Minute, PerParameter: map[string]time.Duration{"k": time.Second, "delta": time.Millisecond}},
+			want:    time.Minute + 10*time.Second + 2*time.Second,
+		},
+		{
+			name:    "per parameter without base is unlimited",
+			timeout: ExperimentTimeout{PerParameter: map[string]time.Duration{"k": time.Second}},
+			want:    0,
+		},
+		{
+			name:    "unknown parameter is ignored",
+			timeout: ExperimentTimeout{Base: time.Minute, PerParameter: map[string]time.Duration{"kappa": time.Hour}},
+			want:    time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.timeout.Of(e); got != test.want {
+				t.Errorf("timeout of %s = %v, want %v", e.ToFileName(), got, test.want)
+			}
+		})
+	}
+}